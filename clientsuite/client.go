@@ -15,41 +15,65 @@
 package clientsuite
 
 import (
+	"context"
 	"strings"
 
 	"github.com/cloudwego/kitex/client"
-	"github.com/cloudwego/kitex/pkg/loadbalance"
-	"github.com/cloudwego/kitex/pkg/rpcinfo"
-	"github.com/cloudwego/kitex/pkg/transmeta"
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/fallback"
+	"github.com/grayscalecloud/kitexcommon/hdregistry"
 	"github.com/grayscalecloud/kitexcommon/utils"
-	"github.com/kitex-contrib/obs-opentelemetry/tracing"
 	consul "github.com/kitex-contrib/registry-consul"
 )
 
 type CommonClientSuite struct {
 	CurrentServiceName string
 	RegistryAddr       string
+	// Retry 连接注册中心的启动重试策略，为 nil 时不重试
+	Retry *hdregistry.RetryPolicy
+	// DestService 下游服务名称，用于查找容错策略
+	DestService string
+	// Resilience 超时、重试和熔断策略，为 nil 时不启用
+	Resilience *Resilience
+	// Fallback 降级策略，为 nil 时不降级
+	Fallback *fallback.Policy
+	// Router 灰度路由器，设置后替代默认的加权负载均衡
+	Router *Router
+	// Zone 同可用区优先负载均衡器，未设置 Router 时生效
+	Zone *ZoneBalancer
 }
 
-func (s CommonClientSuite) Options() []client.Option {
+// Build 返回客户端选项配置，失败时返回错误而不是 panic
+func (s CommonClientSuite) Build() ([]client.Option, error) {
 	// 如果以 ： 开头，则默认为本机地址这里强制指定一下，不然服务发现可能出现不可用的IP
 	if strings.HasPrefix(s.RegistryAddr, ":") {
 		s.RegistryAddr = utils.MustGetLocalIPv4() + s.RegistryAddr
 	}
-	r, err := consul.NewConsulResolver(s.RegistryAddr)
+
+	var r discovery.Resolver
+	err := s.Retry.Do(context.Background(), func() (err error) {
+		r, err = consul.NewConsulResolver(s.RegistryAddr)
+		if err != nil {
+			return hdregistry.NewRegistryError("consul", s.RegistryAddr, err)
+		}
+		return nil
+	})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	opts := []client.Option{
-		client.WithResolver(r),
-		client.WithLoadBalancer(loadbalance.NewWeightedBalancer()), // load balance
-		//client.WithMuxConnection(1),                                // multiplexing 这个在windown 下不支持
-		client.WithMetaHandler(transmeta.ClientTTHeaderHandler), // 使用 TTHeader 协议的元数据处理器
-		client.WithClientBasicInfo(&rpcinfo.EndpointBasicInfo{
-			ServiceName: s.CurrentServiceName,
-		}),
-		client.WithSuite(tracing.NewClientSuite()),
+	opts := append([]client.Option{client.WithResolver(r)}, baseOptions(s.CurrentServiceName, s.Router, s.Zone)...)
+	resOpts, err := resilienceOptions(s.DestService, s.Resilience, s.Fallback)
+	if err != nil {
+		return nil, err
 	}
+	return append(opts, resOpts...), nil
+}
 
+// Options 返回客户端选项配置，失败时 panic
+func (s CommonClientSuite) Options() []client.Option {
+	opts, err := s.Build()
+	if err != nil {
+		panic(err)
+	}
 	return opts
 }
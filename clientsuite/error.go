@@ -2,17 +2,25 @@ package clientsuite
 
 import (
 	"context"
+	"errors"
 
+	"github.com/cloudwego/kitex/pkg/endpoint"
 	"github.com/cloudwego/kitex/pkg/kerrors"
 	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/grayscalecloud/kitexcommon/hderrors"
 )
 
+// minBizErrorCode TransError 的 TypeID 小于该值时为 Kitex 框架内置错误，不按业务错误处理
+const minBizErrorCode = 100
+
 func ClientErrorHandler(ctx context.Context, err error) error {
 	// if you want get other rpc info, you can get rpcinfo first, like `ri := rpcinfo.GetRPCInfo(ctx)`
 	// for example, get remote address: `remoteAddr := rpcinfo.GetRPCInfo(ctx).To().Address()`
 
 	// for thrift、KitexProtobuf
-	if e, ok := err.(*remote.TransError); ok {
+	var e *remote.TransError
+	if errors.As(err, &e) && e.TypeID() >= minBizErrorCode {
 		// TypeID is error code
 		return buildYourError(e.TypeID(), e)
 	}
@@ -21,5 +29,44 @@ func ClientErrorHandler(ctx context.Context, err error) error {
 }
 
 func buildYourError(id int32, e *remote.TransError) error {
-	return nil
+	return hderrors.NewError(hderrors.NewDefaultEnumsType(int64(id)), e.Error())
+}
+
+// BusinessErrorMiddleware 将服务端通过 TTHeader 返回的业务状态码重建为 *hderrors.BusinessError
+// Kitex 的业务状态错误不会经过 ClientErrorHandler，需要通过中间件转换
+func BusinessErrorMiddleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp interface{}) error {
+		if err := next(ctx, req, resp); err != nil {
+			return err
+		}
+		ri := rpcinfo.GetRPCInfo(ctx)
+		if ri == nil || ri.Invocation() == nil {
+			return nil
+		}
+		if bizErr := ri.Invocation().BizStatusErr(); bizErr != nil {
+			return ToBusinessError(bizErr)
+		}
+		return nil
+	}
+}
+
+// ToBusinessError 将 Kitex 业务状态错误转换为 *hderrors.BusinessError，保留错误码、消息和 BizExtra
+// 非业务状态错误原样返回
+func ToBusinessError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var be *hderrors.BusinessError
+	if errors.As(err, &be) {
+		return err
+	}
+
+	bizErr, ok := kerrors.FromBizStatusError(err)
+	if !ok {
+		return err
+	}
+	e := hderrors.NewError(hderrors.NewDefaultEnumsType(int64(bizErr.BizStatusCode())), bizErr.BizMessage())
+	e.SetExtras(bizErr.BizExtra())
+	return e
 }
@@ -0,0 +1,21 @@
+package clientsuite
+
+import (
+	"context"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/grayscalecloud/kitexcommon/ctxx"
+)
+
+// MetaInfoMiddleware 元数据传递中间件，各客户端套件默认启用
+// 将 ctxx.PropagatedKeys() 返回的键的临时值提升为持久化值，请求 ID 不存在时生成一个，并复制当前 span 的追踪 ID，
+// 隔离开关等只能本地设置的值不会传给下游
+func MetaInfoMiddleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp interface{}) error {
+		ctx = ctxx.StripLocal(ctx)
+		ctx = ctxx.Promote(ctx, ctxx.PropagatedKeys()...)
+		ctx, _ = ctxx.EnsureRequestID(ctx)
+		ctx = ctxx.WithSpanTraceID(ctx)
+		return next(ctx, req, resp)
+	}
+}
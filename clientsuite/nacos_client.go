@@ -1,95 +1,107 @@
 package clientsuite
 
 import (
-	"strconv"
+	"context"
 	"strings"
 
 	"github.com/cloudwego/kitex/client"
-	"github.com/cloudwego/kitex/pkg/loadbalance"
-	"github.com/cloudwego/kitex/pkg/rpcinfo"
-	"github.com/cloudwego/kitex/pkg/transmeta"
+	"github.com/cloudwego/kitex/pkg/fallback"
+	"github.com/grayscalecloud/kitexcommon/hdmodel"
+	"github.com/grayscalecloud/kitexcommon/hdregistry"
+	"github.com/grayscalecloud/kitexcommon/lifecycle"
 	"github.com/grayscalecloud/kitexcommon/utils"
-	"github.com/kitex-contrib/obs-opentelemetry/tracing"
 	"github.com/kitex-contrib/registry-nacos/v2/resolver"
-	"github.com/nacos-group/nacos-sdk-go/v2/clients"
-	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
-	"github.com/nacos-group/nacos-sdk-go/v2/vo"
+	naming_client "github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client"
 )
 
 type NacosClientSuite struct {
 	CurrentServiceName string
-	NacosAddr          string
-	NacosPort          uint64
-	NamespaceId        string
-	Username           string
-	Password           string
+	// NacosAddr Nacos 地址，格式：host:port，集群用逗号分隔，支持 IPv6 和 http(s)://host:port/path
+	NacosAddr string
+	// NacosPort 地址未指定端口时使用的端口，默认 8848
+	NacosPort   uint64
+	NamespaceId string
+	Username    string
+	Password    string
+	// ContextPath Nacos 上下文路径
+	ContextPath string
+	// AccessKey Nacos AK 认证
+	AccessKey string
+	// SecretKey Nacos SK 认证
+	SecretKey string
+	// TLS Nacos TLS 配置
+	TLS hdmodel.RegistryTLS
+	// LogDir Nacos 客户端日志目录，默认 /tmp/nacos/log
+	LogDir string
+	// CacheDir Nacos 客户端缓存目录，默认 /tmp/nacos/cache
+	CacheDir string
+	// Retry 连接注册中心的启动重试策略，为 nil 时不重试
+	Retry *hdregistry.RetryPolicy
+	// DestService 下游服务名称，用于查找容错策略
+	DestService string
+	// Resilience 超时、重试和熔断策略，为 nil 时不启用
+	Resilience *Resilience
+	// Fallback 降级策略，为 nil 时不降级
+	Fallback *fallback.Policy
+	// Router 灰度路由器，设置后替代默认的加权负载均衡
+	Router *Router
+	// Zone 同可用区优先负载均衡器，未设置 Router 时生效
+	Zone *ZoneBalancer
+	// Lifecycle 生命周期管理器，关闭时释放 Nacos 客户端引用，默认为 lifecycle.Default()
+	Lifecycle *lifecycle.Lifecycle
 }
 
-func (s NacosClientSuite) Options() []client.Option {
+// Build 返回客户端选项配置，失败时返回错误而不是 panic
+func (s NacosClientSuite) Build() ([]client.Option, error) {
 	// 如果以 ： 开头，则默认为本机地址这里强制指定一下，不然服务发现可能出现不可用的IP
 	if strings.HasPrefix(s.NacosAddr, ":") {
 		s.NacosAddr = utils.MustGetLocalIPv4() + s.NacosAddr
 	}
 
-	var serverAddr string
-	var serverPort uint64
-
-	if s.NacosAddr != "" {
-		addr := strings.Split(s.NacosAddr, ":")
-		if len(addr) >= 1 {
-			serverAddr = addr[0]
-		}
-		if len(addr) >= 2 {
-			// 修复类型转换问题，使用strconv.ParseUint进行转换
-			port, err := strconv.ParseUint(addr[1], 10, 64)
-			if err != nil {
-				serverPort = 8848 // 默认端口
-			} else {
-				serverPort = port
-			}
-		} else {
-			serverPort = 8848 // 默认端口
-		}
-	} else {
-		serverAddr = "127.0.0.1"
-		serverPort = 8848
-	}
-
-	sc := []constant.ServerConfig{
-		*constant.NewServerConfig(serverAddr, serverPort),
+	nacosCfg := hdregistry.NacosConfig{
+		Addrs:       s.NacosAddr,
+		DefaultPort: s.NacosPort,
+		ContextPath: s.ContextPath,
+		NamespaceId: s.NamespaceId,
+		Username:    s.Username,
+		Password:    s.Password,
+		AccessKey:   s.AccessKey,
+		SecretKey:   s.SecretKey,
+		TLS:         s.TLS,
+		LogDir:      s.LogDir,
+		CacheDir:    s.CacheDir,
 	}
-
-	cc := constant.ClientConfig{
-		NamespaceId:         s.NamespaceId,
-		TimeoutMs:           5000,
-		NotLoadCacheAtStart: true,
-		LogDir:              "/tmp/nacos/log",
-		CacheDir:            "/tmp/nacos/cache",
-		LogLevel:            "info",
-		Username:            s.Username,
-		Password:            s.Password,
+	// 提前校验地址，地址错误不需要重试
+	if _, err := nacosCfg.ServerConfigs(); err != nil {
+		return nil, err
 	}
 
-	cli, err := clients.NewNamingClient(
-		vo.NacosClientParam{
-			ClientConfig:  &cc,
-			ServerConfigs: sc,
-		},
-	)
+	// 从全局客户端池获取，相同 Nacos 配置的下游客户端共用一个连接，生命周期管理器关闭时释放引用
+	var cli naming_client.INamingClient
+	var release func()
+	err := s.Retry.Do(context.Background(), func() (err error) {
+		cli, release, err = hdregistry.AcquireNacosNamingClient(nacosCfg)
+		return err
+	})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	registerRelease(s.Lifecycle, release)
 
 	r := resolver.NewNacosResolver(cli)
-	opts := []client.Option{
-		client.WithResolver(r),
-		client.WithLoadBalancer(loadbalance.NewWeightedBalancer()), // load balance
-		client.WithMetaHandler(transmeta.ClientTTHeaderHandler), // 使用 TTHeader 协议的元数据处理器
-		client.WithClientBasicInfo(&rpcinfo.EndpointBasicInfo{
-			ServiceName: s.CurrentServiceName,
-		}),
-		client.WithSuite(tracing.NewClientSuite()),
+	opts := append([]client.Option{client.WithResolver(r)}, baseOptions(s.CurrentServiceName, s.Router, s.Zone)...)
+	resOpts, err := resilienceOptions(s.DestService, s.Resilience, s.Fallback)
+	if err != nil {
+		return nil, err
 	}
+	return append(opts, resOpts...), nil
+}
 
+// Options 返回客户端选项配置，失败时 panic
+func (s NacosClientSuite) Options() []client.Option {
+	opts, err := s.Build()
+	if err != nil {
+		panic(err)
+	}
 	return opts
 }
@@ -0,0 +1,359 @@
+package clientsuite
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/kitex/client"
+	"github.com/cloudwego/kitex/pkg/circuitbreak"
+	"github.com/cloudwego/kitex/pkg/fallback"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/kitex/pkg/retry"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/grayscalecloud/kitexcommon/kvconfig"
+	"gopkg.in/yaml.v2"
+)
+
+// 重试类型
+const (
+	// RetryTypeFailure 失败重试
+	RetryTypeFailure = "failure"
+	// RetryTypeBackup 备份请求，超过 BackupDelayMs 未返回时向其他实例再发一次
+	RetryTypeBackup = "backup"
+)
+
+// 退避类型
+const (
+	BackoffNone   = "none"
+	BackoffFixed  = "fixed"
+	BackoffRandom = "random"
+)
+
+// defaultRetryErrorRate 重试熔断的默认错误率，与 Kitex 默认值一致
+const defaultRetryErrorRate = 0.1
+
+// ResilienceConfig 下游服务的容错策略配置，可以从 kvconfig 加载，例如：
+//
+//	services:
+//	  user:
+//	    default:
+//	      rpc_timeout_ms: 1000
+//	      connect_timeout_ms: 50
+//	      retry: {type: failure, max_retry_times: 2, backoff_type: random, backoff_min_ms: 10, backoff_max_ms: 50}
+//	    methods:
+//	      GetUser:
+//	        rpc_timeout_ms: 300
+//	        retry: {type: backup, max_retry_times: 1, backup_delay_ms: 100}
+//	    circuit_breaker: {enable: true, err_rate: 0.3, min_sample: 100}
+type ResilienceConfig struct {
+	Services map[string]ServicePolicy `yaml:"services"`
+}
+
+// ServicePolicy 单个下游服务的容错策略
+type ServicePolicy struct {
+	// Default 服务级默认策略
+	Default MethodPolicy `yaml:"default"`
+	// Methods 方法级策略，未设置的超时时间和重试策略继承 Default
+	Methods map[string]MethodPolicy `yaml:"methods"`
+	// CircuitBreaker 实例级熔断策略，为 nil 时关闭实例级熔断
+	CircuitBreaker *CircuitBreakerPolicy `yaml:"circuit_breaker"`
+}
+
+// MethodPolicy 方法级容错策略
+type MethodPolicy struct {
+	// RPCTimeoutMs RPC 超时时间（毫秒），0 表示使用客户端默认值
+	RPCTimeoutMs int64 `yaml:"rpc_timeout_ms"`
+	// ConnectTimeoutMs 连接超时时间（毫秒），0 表示使用客户端默认值
+	ConnectTimeoutMs int64 `yaml:"connect_timeout_ms"`
+	// Retry 重试策略，为 nil 时继承服务级策略
+	Retry *RetryPolicy `yaml:"retry"`
+}
+
+// RetryPolicy 重试策略
+type RetryPolicy struct {
+	// Disable 关闭重试，用于在方法级关闭服务级的重试策略
+	Disable bool `yaml:"disable"`
+	// Type 重试类型，failure（默认）或 backup
+	Type string `yaml:"type"`
+	// MaxRetryTimes 最大重试次数，失败重试最多 5 次，备份请求最多 2 次
+	MaxRetryTimes int `yaml:"max_retry_times"`
+	// MaxDurationMs 包含重试在内的总耗时上限（毫秒），0 表示不限制
+	MaxDurationMs uint32 `yaml:"max_duration_ms"`
+	// BackoffType 失败重试的退避类型：none、fixed、random
+	BackoffType string `yaml:"backoff_type"`
+	// BackoffFixedMs fixed 退避的等待时间（毫秒）
+	BackoffFixedMs float64 `yaml:"backoff_fixed_ms"`
+	// BackoffMinMs random 退避的最小等待时间（毫秒）
+	BackoffMinMs float64 `yaml:"backoff_min_ms"`
+	// BackoffMaxMs random 退避的最大等待时间（毫秒）
+	BackoffMaxMs float64 `yaml:"backoff_max_ms"`
+	// BackupDelayMs 备份请求的发送延迟（毫秒）
+	BackupDelayMs uint32 `yaml:"backup_delay_ms"`
+	// RetrySameNode 是否允许重试到同一个实例
+	RetrySameNode bool `yaml:"retry_same_node"`
+	// ErrorRate 重试熔断错误率，超过后停止重试，默认 0.1
+	ErrorRate float64 `yaml:"error_rate"`
+}
+
+// CircuitBreakerPolicy 实例级熔断策略
+type CircuitBreakerPolicy struct {
+	Enable bool `yaml:"enable"`
+	// ErrRate 触发熔断的错误率
+	ErrRate float64 `yaml:"err_rate"`
+	// MinSample 触发熔断的最小请求数
+	MinSample int64 `yaml:"min_sample"`
+}
+
+// ParseResilienceConfig 解析 YAML 或 JSON 格式的容错策略配置
+func ParseResilienceConfig(content string) (ResilienceConfig, error) {
+	var cfg ResilienceConfig
+	if err := yaml.Unmarshal([]byte(content), &cfg); err != nil {
+		return cfg, fmt.Errorf("解析容错策略配置失败: %w", err)
+	}
+	for service, sp := range cfg.Services {
+		if err := sp.validate(); err != nil {
+			return cfg, fmt.Errorf("服务 %s 的容错策略无效: %w", service, err)
+		}
+	}
+	return cfg, nil
+}
+
+// validate 校验重试策略能否转换为 Kitex 策略
+func (p ServicePolicy) validate() error {
+	if _, err := p.Default.Retry.toKitex(); err != nil {
+		return err
+	}
+	for method, mp := range p.Methods {
+		if _, err := mp.Retry.toKitex(); err != nil {
+			return fmt.Errorf("方法 %s: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// toKitex 转换为 Kitex 重试策略
+func (p *RetryPolicy) toKitex() (retry.Policy, error) {
+	if p == nil || p.Disable {
+		return retry.Policy{}, nil
+	}
+
+	stop := retry.StopPolicy{
+		MaxRetryTimes: p.MaxRetryTimes,
+		MaxDurationMS: p.MaxDurationMs,
+		CBPolicy:      retry.CBPolicy{ErrorRate: p.ErrorRate},
+	}
+	if stop.CBPolicy.ErrorRate <= 0 {
+		stop.CBPolicy.ErrorRate = defaultRetryErrorRate
+	}
+
+	switch p.Type {
+	case "", RetryTypeFailure:
+		fp := &retry.FailurePolicy{StopPolicy: stop, RetrySameNode: p.RetrySameNode}
+		switch p.BackoffType {
+		case "", BackoffNone:
+		case BackoffFixed:
+			fp.BackOffPolicy = &retry.BackOffPolicy{
+				BackOffType: retry.FixedBackOffType,
+				CfgItems:    map[retry.BackOffCfgKey]float64{retry.FixMSBackOffCfgKey: p.BackoffFixedMs},
+			}
+		case BackoffRandom:
+			fp.BackOffPolicy = &retry.BackOffPolicy{
+				BackOffType: retry.RandomBackOffType,
+				CfgItems: map[retry.BackOffCfgKey]float64{
+					retry.MinMSBackOffCfgKey: p.BackoffMinMs,
+					retry.MaxMSBackOffCfgKey: p.BackoffMaxMs,
+				},
+			}
+		default:
+			return retry.Policy{}, fmt.Errorf("不支持的退避类型: %s", p.BackoffType)
+		}
+		return retry.BuildFailurePolicy(fp), nil
+	case RetryTypeBackup:
+		return retry.BuildBackupRequest(&retry.BackupPolicy{
+			RetryDelayMS:  p.BackupDelayMs,
+			StopPolicy:    stop,
+			RetrySameNode: p.RetrySameNode,
+		}), nil
+	default:
+		return retry.Policy{}, fmt.Errorf("不支持的重试类型: %s", p.Type)
+	}
+}
+
+// Resilience 客户端容错策略管理器
+// 每个下游服务对应一组 Kitex 重试容器、熔断套件和超时配置，Update 时原地更新，已创建的客户端立即生效
+type Resilience struct {
+	mu      sync.Mutex
+	cfg     ResilienceConfig
+	targets map[string]*resilienceTarget
+}
+
+// resilienceTarget 单个下游服务的容错组件
+type resilienceTarget struct {
+	policy    atomic.Pointer[ServicePolicy]
+	retry     *retry.Container
+	cb        *circuitbreak.CBSuite
+	retryKeys map[string]bool
+}
+
+// NewResilience 创建容错策略管理器
+func NewResilience(cfg ResilienceConfig) *Resilience {
+	return &Resilience{
+		cfg:     cfg,
+		targets: make(map[string]*resilienceTarget),
+	}
+}
+
+// Options 返回指定下游服务的客户端选项：超时、重试、实例级熔断，以及可选的降级策略
+func (r *Resilience) Options(service string, fb *fallback.Policy) []client.Option {
+	r.mu.Lock()
+	t, ok := r.targets[service]
+	if !ok {
+		t = &resilienceTarget{
+			retry: retry.NewRetryContainer(),
+			cb:    circuitbreak.NewCBSuite(circuitbreak.RPCInfo2Key),
+		}
+		t.apply(r.cfg.Services[service])
+		r.targets[service] = t
+	}
+	r.mu.Unlock()
+
+	opts := []client.Option{
+		client.WithTimeoutProvider(t),
+		client.WithRetryContainer(t.retry),
+		client.WithCircuitBreaker(t.cb),
+	}
+	if fb != nil {
+		opts = append(opts, client.WithFallback(fb))
+	}
+	return opts
+}
+
+// Update 更新容错策略，已创建的客户端立即生效
+func (r *Resilience) Update(cfg ResilienceConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cfg = cfg
+	for service, t := range r.targets {
+		t.apply(cfg.Services[service])
+	}
+	klog.Infof("客户端容错策略已更新，共 %d 个服务", len(cfg.Services))
+}
+
+// Watch 从配置中心加载容错策略并监听变化，配置解析失败时保留当前策略
+func (r *Resilience) Watch(f *kvconfig.ConfigFactory, dataId, group string) error {
+	content, err := f.GetKvConfig(dataId, group)
+	if err != nil {
+		return fmt.Errorf("获取容错策略配置失败: %w", err)
+	}
+	cfg, err := ParseResilienceConfig(content)
+	if err != nil {
+		return err
+	}
+	r.Update(cfg)
+
+	return f.ListenConfig(dataId, group, func(content string) {
+		cfg, err := ParseResilienceConfig(content)
+		if err != nil {
+			klog.Errorf("容错策略配置变更无效，继续使用当前策略: %v", err)
+			return
+		}
+		r.Update(cfg)
+	})
+}
+
+// apply 将服务策略写入重试容器和熔断套件，已删除的方法策略同时从重试容器中移除
+func (t *resilienceTarget) apply(p ServicePolicy) {
+	t.policy.Store(&p)
+
+	keys := make(map[string]bool)
+	notify := func(key string, rp *RetryPolicy) {
+		policy, err := rp.toKitex()
+		if err != nil {
+			klog.Errorf("重试策略 %s 无效: %v", key, err)
+			return
+		}
+		t.retry.NotifyPolicyChange(key, policy)
+		keys[key] = true
+	}
+	if p.Default.Retry != nil {
+		notify(retry.Wildcard, p.Default.Retry)
+	}
+	for method, mp := range p.Methods {
+		if mp.Retry != nil {
+			notify(method, mp.Retry)
+		}
+	}
+	for key := range t.retryKeys {
+		if !keys[key] {
+			t.retry.DeletePolicy(key)
+		}
+	}
+	t.retryKeys = keys
+
+	cb := circuitbreak.CBConfig{}
+	if p.CircuitBreaker != nil {
+		cb = circuitbreak.CBConfig{
+			Enable:    p.CircuitBreaker.Enable,
+			ErrRate:   p.CircuitBreaker.ErrRate,
+			MinSample: p.CircuitBreaker.MinSample,
+		}
+	}
+	t.cb.UpdateInstanceCBConfig(cb)
+}
+
+// Timeouts 实现 rpcinfo.TimeoutProvider 接口，方法级超时优先，未设置时使用服务级和客户端默认值
+func (t *resilienceTarget) Timeouts(ri rpcinfo.RPCInfo) rpcinfo.Timeouts {
+	p := t.policy.Load()
+	if p == nil {
+		return nil
+	}
+	rpcTimeout, connTimeout := p.Default.RPCTimeoutMs, p.Default.ConnectTimeoutMs
+	if mp, ok := p.Methods[ri.To().Method()]; ok {
+		if mp.RPCTimeoutMs > 0 {
+			rpcTimeout = mp.RPCTimeoutMs
+		}
+		if mp.ConnectTimeoutMs > 0 {
+			connTimeout = mp.ConnectTimeoutMs
+		}
+	}
+	if rpcTimeout <= 0 && connTimeout <= 0 {
+		return nil
+	}
+
+	cfg := ri.Config()
+	to := timeouts{rpc: cfg.RPCTimeout(), conn: cfg.ConnectTimeout(), rw: cfg.ReadWriteTimeout()}
+	if rpcTimeout > 0 {
+		to.rpc = time.Duration(rpcTimeout) * time.Millisecond
+	}
+	if connTimeout > 0 {
+		to.conn = time.Duration(connTimeout) * time.Millisecond
+	}
+	return to
+}
+
+// timeouts 实现 rpcinfo.Timeouts 接口
+type timeouts struct {
+	rpc  time.Duration
+	conn time.Duration
+	rw   time.Duration
+}
+
+func (t timeouts) RPCTimeout() time.Duration       { return t.rpc }
+func (t timeouts) ConnectTimeout() time.Duration   { return t.conn }
+func (t timeouts) ReadWriteTimeout() time.Duration { return t.rw }
+
+// resilienceOptions 客户端套件共用的容错选项，未配置 Resilience 时只设置降级策略
+func resilienceOptions(destService string, r *Resilience, fb *fallback.Policy) ([]client.Option, error) {
+	if r == nil {
+		if fb == nil {
+			return nil, nil
+		}
+		return []client.Option{client.WithFallback(fb)}, nil
+	}
+	if destService == "" {
+		return nil, fmt.Errorf("启用容错策略时必须指定下游服务名称 DestService")
+	}
+	return r.Options(destService, fb), nil
+}
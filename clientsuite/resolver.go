@@ -0,0 +1,298 @@
+package clientsuite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"gopkg.in/yaml.v2"
+)
+
+// 解析器名称，同时用作 ClientSuite.Registry 的协议前缀
+const (
+	ResolverConsul = "consul"
+	ResolverNacos  = "nacos"
+	ResolverStatic = "static"
+	ResolverDNS    = "dns"
+	ResolverDNSSRV = "dns+srv"
+	ResolverFile   = "file"
+)
+
+// StaticResolver 固定地址列表的解析器，所有目标服务都解析到同一组地址
+type StaticResolver struct {
+	instances []discovery.Instance
+}
+
+// NewStaticResolver 创建固定地址解析器，addrs 为逗号分隔的 host:port
+func NewStaticResolver(addrs string) (*StaticResolver, error) {
+	var instances []discovery.Instance
+	for _, addr := range strings.Split(addrs, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("无效的静态地址 '%s': %w", addr, err)
+		}
+		instances = append(instances, discovery.NewInstance("tcp", addr, discovery.DefaultWeight, nil))
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("静态地址列表不能为空")
+	}
+	return &StaticResolver{instances: instances}, nil
+}
+
+// Target 实现 discovery.Resolver 接口
+func (r *StaticResolver) Target(ctx context.Context, target rpcinfo.EndpointInfo) string {
+	return target.ServiceName()
+}
+
+// Resolve 实现 discovery.Resolver 接口
+func (r *StaticResolver) Resolve(ctx context.Context, desc string) (discovery.Result, error) {
+	return discovery.Result{Cacheable: true, CacheKey: desc, Instances: r.instances}, nil
+}
+
+// Diff 实现 discovery.Resolver 接口
+func (r *StaticResolver) Diff(cacheKey string, prev, next discovery.Result) (discovery.Change, bool) {
+	return discovery.DefaultDiff(cacheKey, prev, next)
+}
+
+// Name 实现 discovery.Resolver 接口
+func (r *StaticResolver) Name() string {
+	return ResolverStatic
+}
+
+// DNSResolver 基于 DNS 的解析器，每次刷新时重新查询
+// A/AAAA 模式下查询 host 的地址并使用固定端口，host 为空时使用目标服务名（如 Kubernetes Headless Service）；
+// SRV 模式下查询 SRV 记录，name 为空时使用目标服务名，端口和权重取自记录
+type DNSResolver struct {
+	host   string
+	port   string
+	srv    bool
+	lookup *net.Resolver
+}
+
+// NewDNSResolver 创建 A/AAAA 记录解析器，addr 格式为 host:port 或 :port
+func NewDNSResolver(addr string) (*DNSResolver, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 DNS 地址 '%s': %w", addr, err)
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return nil, fmt.Errorf("无效的端口号 '%s': %w", port, err)
+	}
+	return &DNSResolver{host: host, port: port, lookup: net.DefaultResolver}, nil
+}
+
+// NewSRVResolver 创建 SRV 记录解析器，name 为完整的 SRV 名称，例如 _user._tcp.example.com
+func NewSRVResolver(name string) *DNSResolver {
+	return &DNSResolver{host: name, srv: true, lookup: net.DefaultResolver}
+}
+
+// Target 实现 discovery.Resolver 接口
+func (r *DNSResolver) Target(ctx context.Context, target rpcinfo.EndpointInfo) string {
+	if r.host != "" {
+		return r.host
+	}
+	return target.ServiceName()
+}
+
+// Resolve 实现 discovery.Resolver 接口
+func (r *DNSResolver) Resolve(ctx context.Context, desc string) (discovery.Result, error) {
+	var instances []discovery.Instance
+	if r.srv {
+		_, records, err := r.lookup.LookupSRV(ctx, "", "", desc)
+		if err != nil {
+			return discovery.Result{}, fmt.Errorf("查询 SRV 记录 %s 失败: %w", desc, err)
+		}
+		for _, rec := range records {
+			weight := int(rec.Weight)
+			if weight <= 0 {
+				weight = discovery.DefaultWeight
+			}
+			addr := net.JoinHostPort(strings.TrimSuffix(rec.Target, "."), strconv.Itoa(int(rec.Port)))
+			instances = append(instances, discovery.NewInstance("tcp", addr, weight, nil))
+		}
+	} else {
+		ips, err := r.lookup.LookupHost(ctx, desc)
+		if err != nil {
+			return discovery.Result{}, fmt.Errorf("查询 DNS 记录 %s 失败: %w", desc, err)
+		}
+		for _, ip := range ips {
+			instances = append(instances, discovery.NewInstance("tcp", net.JoinHostPort(ip, r.port), discovery.DefaultWeight, nil))
+		}
+	}
+	return discovery.Result{Cacheable: true, CacheKey: desc, Instances: instances}, nil
+}
+
+// Diff 实现 discovery.Resolver 接口
+func (r *DNSResolver) Diff(cacheKey string, prev, next discovery.Result) (discovery.Change, bool) {
+	return discovery.DefaultDiff(cacheKey, prev, next)
+}
+
+// Name 实现 discovery.Resolver 接口
+func (r *DNSResolver) Name() string {
+	if r.srv {
+		return ResolverDNSSRV
+	}
+	return ResolverDNS
+}
+
+// FileEndpoint 文件解析器中的服务实例，可以直接写成 host:port 字符串
+type FileEndpoint struct {
+	Address string            `yaml:"address" json:"address"`
+	Weight  int               `yaml:"weight" json:"weight"`
+	Tags    map[string]string `yaml:"tags" json:"tags"`
+}
+
+// UnmarshalYAML 支持字符串和对象两种写法
+func (e *FileEndpoint) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var addr string
+	if err := unmarshal(&addr); err == nil {
+		e.Address = addr
+		return nil
+	}
+	type plain FileEndpoint
+	return unmarshal((*plain)(e))
+}
+
+// UnmarshalJSON 支持字符串和对象两种写法
+func (e *FileEndpoint) UnmarshalJSON(data []byte) error {
+	var addr string
+	if err := json.Unmarshal(data, &addr); err == nil {
+		e.Address = addr
+		return nil
+	}
+	type plain FileEndpoint
+	return json.Unmarshal(data, (*plain)(e))
+}
+
+// FileResolver 基于 YAML/JSON 文件的解析器，文件内容为服务名到实例列表的映射，例如：
+//
+//	user:
+//	  - 127.0.0.1:8881
+//	  - address: 127.0.0.1:8882
+//	    weight: 20
+//	    tags: {lane: dev}
+//
+// Kitex 定时刷新解析结果时会检查文件修改时间，文件变化后自动重新加载，解析失败时保留上一次的结果
+type FileResolver struct {
+	path string
+
+	mu        sync.RWMutex
+	modTime   time.Time
+	size      int64
+	endpoints map[string][]discovery.Instance
+}
+
+// NewFileResolver 创建文件解析器，根据扩展名选择 JSON 或 YAML 格式
+func NewFileResolver(path string) (*FileResolver, error) {
+	r := &FileResolver{path: path}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Target 实现 discovery.Resolver 接口
+func (r *FileResolver) Target(ctx context.Context, target rpcinfo.EndpointInfo) string {
+	return target.ServiceName()
+}
+
+// Resolve 实现 discovery.Resolver 接口
+func (r *FileResolver) Resolve(ctx context.Context, desc string) (discovery.Result, error) {
+	if err := r.reload(); err != nil {
+		klog.Warnf("重新加载服务地址文件失败，继续使用上一次的结果: %v", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	instances, ok := r.endpoints[desc]
+	if !ok {
+		return discovery.Result{}, fmt.Errorf("服务地址文件 %s 中未配置服务 %s", r.path, desc)
+	}
+	return discovery.Result{Cacheable: true, CacheKey: desc, Instances: instances}, nil
+}
+
+// Diff 实现 discovery.Resolver 接口
+func (r *FileResolver) Diff(cacheKey string, prev, next discovery.Result) (discovery.Change, bool) {
+	return discovery.DefaultDiff(cacheKey, prev, next)
+}
+
+// Name 实现 discovery.Resolver 接口
+func (r *FileResolver) Name() string {
+	return ResolverFile
+}
+
+// reload 文件修改时间或大小变化时重新加载
+func (r *FileResolver) reload() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return fmt.Errorf("读取服务地址文件 %s 失败: %w", r.path, err)
+	}
+
+	r.mu.RLock()
+	unchanged := r.endpoints != nil && info.ModTime().Equal(r.modTime) && info.Size() == r.size
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("读取服务地址文件 %s 失败: %w", r.path, err)
+	}
+	endpoints, err := parseEndpointFile(r.path, data)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.endpoints = endpoints
+	r.modTime = info.ModTime()
+	r.size = info.Size()
+	r.mu.Unlock()
+	klog.Infof("已加载服务地址文件 %s，共 %d 个服务", r.path, len(endpoints))
+	return nil
+}
+
+// parseEndpointFile 解析服务地址文件
+func parseEndpointFile(path string, data []byte) (map[string][]discovery.Instance, error) {
+	raw := make(map[string][]FileEndpoint)
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析服务地址文件 %s 失败: %w", path, err)
+	}
+
+	endpoints := make(map[string][]discovery.Instance, len(raw))
+	for service, list := range raw {
+		instances := make([]discovery.Instance, 0, len(list))
+		for _, e := range list {
+			if _, _, err := net.SplitHostPort(e.Address); err != nil {
+				return nil, fmt.Errorf("服务 %s 的地址 '%s' 无效: %w", service, e.Address, err)
+			}
+			weight := e.Weight
+			if weight <= 0 {
+				weight = discovery.DefaultWeight
+			}
+			instances = append(instances, discovery.NewInstance("tcp", e.Address, weight, e.Tags))
+		}
+		endpoints[service] = instances
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,343 @@
+package clientsuite
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/grayscalecloud/kitexcommon/ctxx"
+	"github.com/grayscalecloud/kitexcommon/hdregistry"
+	"github.com/grayscalecloud/kitexcommon/kvconfig"
+	"gopkg.in/yaml.v2"
+)
+
+// RouterName 标签路由负载均衡器名称
+const RouterName = "tag_router"
+
+// RouteConfig 灰度路由规则配置，可以从 kvconfig 加载，例如：
+//
+//	rules:
+//	  - name: vip-tenants
+//	    tenant_ids: [t1001, t1002]
+//	    target: {tenant_group: vip}
+//	  - name: gray-10-percent
+//	    services: [order]
+//	    app_types: [merchant]
+//	    percentage: 10
+//	    target: {lane: gray}
+type RouteConfig struct {
+	Rules []RouteRule `yaml:"rules"`
+}
+
+// RouteRule 路由规则，所有非空条件同时满足时命中，列表内任一值满足即可
+type RouteRule struct {
+	// Name 规则名称，同时作为百分比分桶的盐值
+	Name string `yaml:"name"`
+	// Services 适用的下游服务，为空时适用于所有服务
+	Services []string `yaml:"services"`
+	// Methods 适用的方法，为空时适用于所有方法
+	Methods []string `yaml:"methods"`
+	// TenantIDs 匹配的租户 ID
+	TenantIDs []string `yaml:"tenant_ids"`
+	// AppTypes 匹配的应用类型
+	AppTypes []string `yaml:"app_types"`
+	// UserIDs 匹配的用户 ID
+	UserIDs []string `yaml:"user_ids"`
+	// Percentage 按用户 ID（没有时按租户 ID）哈希命中的百分比，取值 0-100，0 表示不限制
+	Percentage float64 `yaml:"percentage"`
+	// Target 命中后选择的实例元数据，例如 {lane: gray}、{version: v2}
+	// 包含 lane 时泳道会写入持久化 metainfo，后续各跳调用都路由到该泳道
+	Target map[string]string `yaml:"target"`
+}
+
+// ParseRouteConfig 解析 YAML 或 JSON 格式的路由规则配置
+func ParseRouteConfig(content string) (RouteConfig, error) {
+	var cfg RouteConfig
+	if err := yaml.Unmarshal([]byte(content), &cfg); err != nil {
+		return cfg, fmt.Errorf("解析路由规则配置失败: %w", err)
+	}
+	for i, rule := range cfg.Rules {
+		if len(rule.Target) == 0 {
+			return cfg, fmt.Errorf("路由规则 %d（%s）未指定 target", i, rule.Name)
+		}
+		if rule.Percentage < 0 || rule.Percentage > 100 {
+			return cfg, fmt.Errorf("路由规则 %d（%s）的百分比必须在 0-100 之间", i, rule.Name)
+		}
+	}
+	return cfg, nil
+}
+
+// match 判断请求是否命中规则
+func (r *RouteRule) match(ctx context.Context, service, method string) bool {
+	if !matchAny(r.Services, service) || !matchAny(r.Methods, method) {
+		return false
+	}
+	tenantID := ctxx.GetTenantID(ctx)
+	userID := ctxx.GetUserID(ctx)
+	if !matchAny(r.TenantIDs, tenantID) || !matchAny(r.AppTypes, ctxx.GetAppType(ctx)) || !matchAny(r.UserIDs, userID) {
+		return false
+	}
+	if r.Percentage > 0 {
+		key := userID
+		if key == "" {
+			key = tenantID
+		}
+		if key == "" {
+			return false
+		}
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(r.Name + ":" + key))
+		return float64(h.Sum32()%10000) < r.Percentage*100
+	}
+	return true
+}
+
+// matchAny 列表为空时视为匹配
+func matchAny(list []string, v string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// routeTargetKey 本跳路由目标在 context 中的键，只在当前进程内有效
+type routeTargetKey struct{}
+
+// Router 基于实例元数据的灰度路由器
+// 作为客户端中间件按规则为请求选择目标实例，作为负载均衡器在目标实例中按权重随机选择，
+// 目标实例不可用时回退到默认泳道
+type Router struct {
+	cfg    atomic.Pointer[RouteConfig]
+	tables sync.Map // map[cacheKey]*routeTable
+}
+
+// NewRouter 创建灰度路由器
+func NewRouter(cfg RouteConfig) *Router {
+	r := &Router{}
+	r.cfg.Store(&cfg)
+	return r
+}
+
+// Update 更新路由规则，立即生效
+func (r *Router) Update(cfg RouteConfig) {
+	r.cfg.Store(&cfg)
+	klog.Infof("灰度路由规则已更新，共 %d 条规则", len(cfg.Rules))
+}
+
+// Watch 从配置中心加载路由规则并监听变化，配置解析失败时保留当前规则
+func (r *Router) Watch(f *kvconfig.ConfigFactory, dataId, group string) error {
+	content, err := f.GetKvConfig(dataId, group)
+	if err != nil {
+		return fmt.Errorf("获取路由规则配置失败: %w", err)
+	}
+	cfg, err := ParseRouteConfig(content)
+	if err != nil {
+		return err
+	}
+	r.Update(cfg)
+
+	return f.ListenConfig(dataId, group, func(content string) {
+		cfg, err := ParseRouteConfig(content)
+		if err != nil {
+			klog.Errorf("路由规则配置变更无效，继续使用当前规则: %v", err)
+			return
+		}
+		r.Update(cfg)
+	})
+}
+
+// Middleware 按路由规则为请求确定目标，请求已带泳道时沿用泳道（粘性泳道）
+func (r *Router) Middleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp interface{}) error {
+		if ctxx.GetLane(ctx) == "" {
+			if ri := rpcinfo.GetRPCInfo(ctx); ri != nil {
+				ctx = r.route(ctx, ri.To().ServiceName(), ri.To().Method())
+			}
+		}
+		return next(ctx, req, resp)
+	}
+}
+
+// route 匹配第一条命中的规则
+func (r *Router) route(ctx context.Context, service, method string) context.Context {
+	cfg := r.cfg.Load()
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		if !rule.match(ctx, service, method) {
+			continue
+		}
+		if lane := rule.Target[hdregistry.MetaLane]; lane != "" {
+			ctx = ctxx.WithLane(ctx, lane)
+		}
+		return context.WithValue(ctx, routeTargetKey{}, rule.Target)
+	}
+	return ctx
+}
+
+// GetPicker 实现 loadbalance.Loadbalancer 接口
+func (r *Router) GetPicker(res discovery.Result) loadbalance.Picker {
+	if !res.Cacheable {
+		return &routePicker{table: newRouteTable(res.Instances)}
+	}
+	if t, ok := r.tables.Load(res.CacheKey); ok {
+		return &routePicker{table: t.(*routeTable)}
+	}
+	t, _ := r.tables.LoadOrStore(res.CacheKey, newRouteTable(res.Instances))
+	return &routePicker{table: t.(*routeTable)}
+}
+
+// Rebalance 实现 loadbalance.Rebalancer 接口，实例变化时重建路由表
+func (r *Router) Rebalance(change discovery.Change) {
+	if !change.Result.Cacheable {
+		return
+	}
+	r.tables.Store(change.Result.CacheKey, newRouteTable(change.Result.Instances))
+}
+
+// Delete 实现 loadbalance.Rebalancer 接口
+func (r *Router) Delete(change discovery.Change) {
+	if !change.Result.Cacheable {
+		return
+	}
+	r.tables.Delete(change.Result.CacheKey)
+}
+
+// Name 实现 loadbalance.Loadbalancer 接口
+func (r *Router) Name() string {
+	return RouterName
+}
+
+// routeTable 一次服务发现结果对应的路由表，按目标缓存实例子集
+type routeTable struct {
+	instances   []discovery.Instance
+	defaultPool []discovery.Instance
+	subsets     sync.Map // map[selectorKey][]discovery.Instance
+}
+
+func newRouteTable(instances []discovery.Instance) *routeTable {
+	t := &routeTable{instances: instances}
+	for _, ins := range instances {
+		if lane, _ := ins.Tag(hdregistry.MetaLane); lane == "" || lane == hdregistry.MetaDefaultLane {
+			t.defaultPool = append(t.defaultPool, ins)
+		}
+	}
+	// 所有实例都属于某个泳道时，默认泳道为全部实例
+	if len(t.defaultPool) == 0 {
+		t.defaultPool = instances
+	}
+	return t
+}
+
+// candidates 返回与目标匹配的实例，没有匹配实例时回退到默认泳道
+func (t *routeTable) candidates(target map[string]string) []discovery.Instance {
+	if len(target) == 0 {
+		return t.defaultPool
+	}
+	if len(target) == 1 && target[hdregistry.MetaLane] == hdregistry.MetaDefaultLane {
+		return t.defaultPool
+	}
+
+	key := selectorKey(target)
+	if v, ok := t.subsets.Load(key); ok {
+		return v.([]discovery.Instance)
+	}
+	var subset []discovery.Instance
+	for _, ins := range t.instances {
+		if matchTags(ins, target) {
+			subset = append(subset, ins)
+		}
+	}
+	if len(subset) == 0 {
+		klog.Debugf("没有符合 %s 的实例，回退到默认泳道", key)
+		subset = t.defaultPool
+	}
+	t.subsets.Store(key, subset)
+	return subset
+}
+
+// matchTags 实例元数据包含目标的所有键值时匹配，目标泳道为 default 时匹配未设置泳道的实例
+func matchTags(ins discovery.Instance, target map[string]string) bool {
+	for k, v := range target {
+		tag, _ := ins.Tag(k)
+		if k == hdregistry.MetaLane && v == hdregistry.MetaDefaultLane && tag == "" {
+			continue
+		}
+		if tag != v {
+			return false
+		}
+	}
+	return true
+}
+
+// selectorKey 生成与键顺序无关的目标缓存键
+func selectorKey(target map[string]string) string {
+	pairs := make([]string, 0, len(target))
+	for k, v := range target {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// routePicker 在目标实例中按权重随机选择
+type routePicker struct {
+	table *routeTable
+}
+
+// Next 实现 loadbalance.Picker 接口
+func (p *routePicker) Next(ctx context.Context, request interface{}) discovery.Instance {
+	target, _ := ctx.Value(routeTargetKey{}).(map[string]string)
+	if target == nil {
+		if lane := ctxx.GetLane(ctx); lane != "" {
+			target = map[string]string{hdregistry.MetaLane: lane}
+		}
+	}
+	return pickWeighted(p.table.candidates(target))
+}
+
+// pickWeighted 按权重随机选择实例，权重都不大于 0 时等概率选择
+func pickWeighted(instances []discovery.Instance) discovery.Instance {
+	switch len(instances) {
+	case 0:
+		return nil
+	case 1:
+		return instances[0]
+	}
+
+	total := 0
+	for _, ins := range instances {
+		if w := ins.Weight(); w > 0 {
+			total += w
+		}
+	}
+	if total == 0 {
+		return instances[rand.IntN(len(instances))]
+	}
+	n := rand.IntN(total)
+	for _, ins := range instances {
+		w := ins.Weight()
+		if w <= 0 {
+			continue
+		}
+		if n < w {
+			return ins
+		}
+		n -= w
+	}
+	return instances[len(instances)-1]
+}
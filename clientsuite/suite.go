@@ -0,0 +1,190 @@
+package clientsuite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cloudwego/kitex/client"
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/fallback"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/transmeta"
+	"github.com/grayscalecloud/kitexcommon/hdregistry"
+	"github.com/grayscalecloud/kitexcommon/lifecycle"
+	"github.com/grayscalecloud/kitexcommon/utils"
+	"github.com/kitex-contrib/obs-opentelemetry/tracing"
+	consul "github.com/kitex-contrib/registry-consul"
+	"github.com/kitex-contrib/registry-nacos/v2/resolver"
+)
+
+// ClientSuite 按配置选择服务发现方式的客户端套件，本地开发和测试时可以不依赖注册中心
+type ClientSuite struct {
+	CurrentServiceName string
+	// Registry 服务发现地址，按协议前缀选择解析器：
+	//   - consul://host:port
+	//   - nacos://host:port,host:port（其他连接参数取自 Nacos）
+	//   - static://host:port,host:port
+	//   - dns://host:port 或 dns://:port（使用目标服务名查询 A/AAAA 记录）
+	//   - dns+srv://_service._tcp.example.com 或 dns+srv://（使用目标服务名查询 SRV 记录）
+	//   - file:///path/to/endpoints.yaml（支持 .yaml/.yml/.json，文件变化后自动重新加载）
+	Registry string
+	// Nacos Nacos 连接参数，Addrs 由 Registry 覆盖
+	Nacos hdregistry.NacosConfig
+	// Retry 连接注册中心的启动重试策略，为 nil 时不重试
+	Retry *hdregistry.RetryPolicy
+	// DestService 下游服务名称，用于查找容错策略
+	DestService string
+	// Resilience 超时、重试和熔断策略，为 nil 时不启用
+	Resilience *Resilience
+	// Fallback 降级策略，为 nil 时不降级
+	Fallback *fallback.Policy
+	// Router 灰度路由器，设置后替代默认的加权负载均衡
+	Router *Router
+	// Zone 同可用区优先负载均衡器，未设置 Router 时生效
+	Zone *ZoneBalancer
+	// Lifecycle 生命周期管理器，关闭时释放 Nacos 客户端引用，默认为 lifecycle.Default()
+	Lifecycle *lifecycle.Lifecycle
+}
+
+// Build 返回客户端选项配置，失败时返回错误而不是 panic
+func (s ClientSuite) Build() ([]client.Option, error) {
+	r, err := s.resolver()
+	if err != nil {
+		return nil, err
+	}
+	opts := append([]client.Option{client.WithResolver(r)}, baseOptions(s.CurrentServiceName, s.Router, s.Zone)...)
+	resOpts, err := resilienceOptions(s.DestService, s.Resilience, s.Fallback)
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, resOpts...), nil
+}
+
+// Options 返回客户端选项配置，失败时 panic
+func (s ClientSuite) Options() []client.Option {
+	opts, err := s.Build()
+	if err != nil {
+		panic(err)
+	}
+	return opts
+}
+
+// resolver 根据 Registry 的协议前缀创建解析器
+func (s ClientSuite) resolver() (discovery.Resolver, error) {
+	scheme, addr, ok := strings.Cut(s.Registry, "://")
+	if !ok {
+		return nil, hdregistry.NewInvalidAddressError("", s.Registry, fmt.Errorf("缺少协议前缀"))
+	}
+	// 如果以 ： 开头，则默认为本机地址这里强制指定一下，不然服务发现可能出现不可用的IP
+	if (scheme == ResolverConsul || scheme == ResolverNacos) && strings.HasPrefix(addr, ":") {
+		addr = utils.MustGetLocalIPv4() + addr
+	}
+
+	switch scheme {
+	case ResolverConsul:
+		var r discovery.Resolver
+		err := s.Retry.Do(context.Background(), func() (err error) {
+			r, err = consul.NewConsulResolver(addr)
+			if err != nil {
+				return hdregistry.NewRegistryError(ResolverConsul, addr, err)
+			}
+			return nil
+		})
+		return r, err
+	case ResolverNacos:
+		nacosCfg := s.Nacos
+		nacosCfg.Addrs = addr
+		if _, err := nacosCfg.ServerConfigs(); err != nil {
+			return nil, err
+		}
+		var r discovery.Resolver
+		err := s.Retry.Do(context.Background(), func() error {
+			cli, release, err := hdregistry.AcquireNacosNamingClient(nacosCfg)
+			if err != nil {
+				return err
+			}
+			registerRelease(s.Lifecycle, release)
+			r = resolver.NewNacosResolver(cli)
+			return nil
+		})
+		return r, err
+	case ResolverStatic:
+		r, err := NewStaticResolver(addr)
+		if err != nil {
+			return nil, hdregistry.NewInvalidAddressError(ResolverStatic, addr, err)
+		}
+		return r, nil
+	case ResolverDNS:
+		r, err := NewDNSResolver(addr)
+		if err != nil {
+			return nil, hdregistry.NewInvalidAddressError(ResolverDNS, addr, err)
+		}
+		return r, nil
+	case ResolverDNSSRV:
+		return NewSRVResolver(addr), nil
+	case ResolverFile:
+		r, err := NewFileResolver(addr)
+		if err != nil {
+			return nil, hdregistry.NewInvalidAddressError(ResolverFile, addr, err)
+		}
+		return r, nil
+	default:
+		return nil, hdregistry.NewInvalidAddressError(scheme, s.Registry, fmt.Errorf("不支持的服务发现方式 '%s'", scheme))
+	}
+}
+
+// ComponentNacosClient 客户端套件注册到生命周期管理器的 Nacos 客户端引用名称前缀，后接序号
+const ComponentNacosClient = "clientsuite_nacos_client"
+
+// nacosClientSeq 客户端套件 Nacos 客户端引用的序号，每个套件的引用单独注册
+var nacosClientSeq atomic.Int64
+
+// registerRelease 将 Nacos 客户端引用的释放注册到生命周期管理器，最后一个引用释放时客户端才真正关闭
+func registerRelease(l *lifecycle.Lifecycle, release func()) {
+	if l == nil {
+		l = lifecycle.Default()
+	}
+	name := fmt.Sprintf("%s_%d", ComponentNacosClient, nacosClientSeq.Add(1))
+	err := l.Register(name, lifecycle.Hook{
+		OnStop: func(ctx context.Context) error {
+			release()
+			return nil
+		},
+	}, lifecycle.Started())
+	if err != nil {
+		klog.Warnf("注册生命周期组件失败: %v", err)
+	}
+}
+
+// baseOptions 各客户端套件共用的选项：负载均衡、TTHeader、基本信息、链路追踪、错误处理和元数据传递
+// router 不为 nil 时使用灰度路由替代默认的加权负载均衡，否则 zone 不为 nil 时使用同可用区优先负载均衡
+func baseOptions(currentServiceName string, router *Router, zone *ZoneBalancer) []client.Option {
+	var lb loadbalance.Loadbalancer = loadbalance.NewWeightedBalancer()
+	switch {
+	case router != nil:
+		lb = router
+	case zone != nil:
+		lb = zone
+	}
+	opts := []client.Option{
+		client.WithLoadBalancer(lb),                             // load balance
+		client.WithMetaHandler(transmeta.ClientTTHeaderHandler), // 使用 TTHeader 协议的元数据处理器
+		client.WithClientBasicInfo(&rpcinfo.EndpointBasicInfo{
+			ServiceName: currentServiceName,
+		}),
+		client.WithSuite(tracing.NewClientSuite()),
+		client.WithErrorHandler(ClientErrorHandler),
+		client.WithMiddleware(BusinessErrorMiddleware),
+		client.WithMiddleware(MetaInfoMiddleware),
+	}
+	if router != nil {
+		opts = append(opts, client.WithMiddleware(router.Middleware))
+	} else if zone != nil {
+		opts = append(opts, client.WithMiddleware(zone.Middleware))
+	}
+	return opts
+}
@@ -0,0 +1,281 @@
+package clientsuite
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cloudwego/kitex/pkg/discovery"
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/kitex/pkg/loadbalance"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/grayscalecloud/kitexcommon/hdregistry"
+	"github.com/grayscalecloud/kitexcommon/monitor"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ZoneBalancerName 同可用区优先负载均衡器名称
+const ZoneBalancerName = "zone_aware"
+
+// 可用区负载均衡的默认阈值
+const (
+	DefaultMinHealthyRatio = 0.5
+	DefaultOverloadRatio   = 2.0
+)
+
+// 跨可用区溢出的原因，用作指标标签
+const (
+	spilloverNoLocal    = "no_local"
+	spilloverUnhealthy  = "unhealthy"
+	spilloverOverloaded = "overloaded"
+)
+
+// unknownZone 实例未设置可用区时的指标标签
+const unknownZone = "unknown"
+
+var (
+	zonePicks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kitex_client_zone_picks_total",
+		Help: "按可用区统计的客户端实例选择次数",
+	}, []string{"service", "zone"})
+	zoneSpillovers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kitex_client_zone_spillovers_total",
+		Help: "按原因统计的跨可用区溢出次数",
+	}, []string{"service", "reason"})
+
+	zoneMetricsMu  sync.Mutex
+	zoneMetricsReg *prometheus.Registry
+)
+
+// registerZoneMetrics 将可用区指标注册到 monitor.Reg，monitor 未开启 Prometheus 时只计数不暴露
+func registerZoneMetrics() {
+	zoneMetricsMu.Lock()
+	defer zoneMetricsMu.Unlock()
+	if monitor.Reg == nil || monitor.Reg == zoneMetricsReg {
+		return
+	}
+	for _, c := range []prometheus.Collector{zonePicks, zoneSpillovers} {
+		var are prometheus.AlreadyRegisteredError
+		if err := monitor.Reg.Register(c); err != nil && !errors.As(err, &are) {
+			klog.Warnf("注册可用区负载均衡指标失败: %v", err)
+			return
+		}
+	}
+	zoneMetricsReg = monitor.Reg
+}
+
+// ZoneConfig 同可用区优先负载均衡配置
+type ZoneConfig struct {
+	// Zone 调用方所在可用区，为空时取 ZONE 环境变量，仍为空时不区分可用区
+	Zone string `yaml:"zone"`
+	// MinHealthyRatio 本可用区可用实例数与历史最多实例数之比低于该值时视为不健康，流量分摊到所有可用区，默认 0.5
+	MinHealthyRatio float64 `yaml:"min_healthy_ratio"`
+	// OverloadRatio 本可用区实例平均在途请求数超过其他可用区（至少按 1 计算）的该倍数时视为过载，
+	// 新请求溢出到其他可用区，默认 2，小于 0 时不做过载判断
+	OverloadRatio float64 `yaml:"overload_ratio"`
+}
+
+// ZoneBalancer 同可用区优先的负载均衡器，实例可用区取自注册中心元数据 hdregistry.MetaZone
+// 需要同时使用 Middleware 统计在途请求，否则不做过载判断
+type ZoneBalancer struct {
+	zone       string
+	minHealthy float64
+	overload   float64
+
+	tables sync.Map // map[cacheKey]*zoneTable
+	states sync.Map // map[cacheKey]*zoneState，实例变化时保留
+}
+
+// NewZoneBalancer 创建同可用区优先负载均衡器，需要在 monitor 初始化之后调用才能暴露指标
+func NewZoneBalancer(cfg ZoneConfig) *ZoneBalancer {
+	if cfg.Zone == "" {
+		cfg.Zone = os.Getenv(hdregistry.EnvZone)
+	}
+	if cfg.MinHealthyRatio <= 0 {
+		cfg.MinHealthyRatio = DefaultMinHealthyRatio
+	}
+	if cfg.OverloadRatio == 0 {
+		cfg.OverloadRatio = DefaultOverloadRatio
+	}
+	if cfg.Zone == "" {
+		klog.Warnf("未设置调用方可用区，%s 负载均衡退化为加权随机", ZoneBalancerName)
+	}
+	registerZoneMetrics()
+	return &ZoneBalancer{zone: cfg.Zone, minHealthy: cfg.MinHealthyRatio, overload: cfg.OverloadRatio}
+}
+
+// zonePickKey 本次调用所选实例的在途计数器在 context 中的键
+type zonePickKey struct{}
+
+// zonePick 记录本次调用计入的在途计数器，同一次调用重新选择实例时先撤销上一次的计数
+type zonePick struct {
+	counter *atomic.Int64
+}
+
+func (p *zonePick) done() {
+	if p.counter != nil {
+		p.counter.Add(-1)
+		p.counter = nil
+	}
+}
+
+// Middleware 统计各可用区的在途请求数，供过载判断使用
+func (z *ZoneBalancer) Middleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp interface{}) error {
+		p := &zonePick{}
+		defer p.done()
+		return next(context.WithValue(ctx, zonePickKey{}, p), req, resp)
+	}
+}
+
+// GetPicker 实现 loadbalance.Loadbalancer 接口
+func (z *ZoneBalancer) GetPicker(res discovery.Result) loadbalance.Picker {
+	if !res.Cacheable {
+		return &zonePicker{b: z, table: z.newTable(res.Instances, &zoneState{})}
+	}
+	if t, ok := z.tables.Load(res.CacheKey); ok {
+		return &zonePicker{b: z, table: t.(*zoneTable)}
+	}
+	t, _ := z.tables.LoadOrStore(res.CacheKey, z.newTable(res.Instances, z.state(res.CacheKey)))
+	return &zonePicker{b: z, table: t.(*zoneTable)}
+}
+
+// Rebalance 实现 loadbalance.Rebalancer 接口，实例变化时重建可用区分组
+func (z *ZoneBalancer) Rebalance(change discovery.Change) {
+	if !change.Result.Cacheable {
+		return
+	}
+	key := change.Result.CacheKey
+	z.tables.Store(key, z.newTable(change.Result.Instances, z.state(key)))
+}
+
+// Delete 实现 loadbalance.Rebalancer 接口
+func (z *ZoneBalancer) Delete(change discovery.Change) {
+	if !change.Result.Cacheable {
+		return
+	}
+	z.tables.Delete(change.Result.CacheKey)
+	z.states.Delete(change.Result.CacheKey)
+}
+
+// Name 实现 loadbalance.Loadbalancer 接口
+func (z *ZoneBalancer) Name() string {
+	return ZoneBalancerName
+}
+
+func (z *ZoneBalancer) state(key string) *zoneState {
+	s, _ := z.states.LoadOrStore(key, &zoneState{})
+	return s.(*zoneState)
+}
+
+// zoneState 跨实例变化保留的状态
+type zoneState struct {
+	// localPeak 观察到的本可用区最多实例数，用于计算健康比例
+	localPeak atomic.Int64
+	// localInflight 发往本可用区的在途请求数
+	localInflight atomic.Int64
+	// remoteInflight 发往其他可用区的在途请求数
+	remoteInflight atomic.Int64
+}
+
+// zoneTable 一次服务发现结果按可用区分组
+type zoneTable struct {
+	state     *zoneState
+	instances []discovery.Instance
+	local     []discovery.Instance
+	remote    []discovery.Instance
+}
+
+func (z *ZoneBalancer) newTable(instances []discovery.Instance, state *zoneState) *zoneTable {
+	t := &zoneTable{state: state, instances: instances}
+	if z.zone == "" {
+		return t
+	}
+	for _, ins := range instances {
+		if zone, _ := ins.Tag(hdregistry.MetaZone); zone == z.zone {
+			t.local = append(t.local, ins)
+		} else {
+			t.remote = append(t.remote, ins)
+		}
+	}
+	for n := int64(len(t.local)); ; {
+		peak := state.localPeak.Load()
+		if n <= peak || state.localPeak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	return t
+}
+
+// zonePicker 优先选择本可用区实例
+type zonePicker struct {
+	b     *ZoneBalancer
+	table *zoneTable
+}
+
+// Next 实现 loadbalance.Picker 接口
+func (p *zonePicker) Next(ctx context.Context, request interface{}) discovery.Instance {
+	t := p.table
+	service := ""
+	if ri := rpcinfo.GetRPCInfo(ctx); ri != nil {
+		service = ri.To().ServiceName()
+	}
+
+	candidates := t.instances
+	if p.b.zone != "" {
+		reason := ""
+		switch {
+		case len(t.local) == 0:
+			reason = spilloverNoLocal
+		case float64(len(t.local)) < p.b.minHealthy*float64(t.state.localPeak.Load()):
+			// 本可用区实例大量下线，流量分摊到所有可用区
+			reason = spilloverUnhealthy
+		case p.overloaded():
+			reason = spilloverOverloaded
+			candidates = t.remote
+		default:
+			candidates = t.local
+		}
+		if reason != "" && len(t.remote) > 0 {
+			zoneSpillovers.WithLabelValues(service, reason).Inc()
+		}
+	}
+
+	ins := pickWeighted(candidates)
+	if ins == nil {
+		return nil
+	}
+	zone, _ := ins.Tag(hdregistry.MetaZone)
+	if zone == "" {
+		zone = unknownZone
+	}
+	zonePicks.WithLabelValues(service, zone).Inc()
+
+	if pick, ok := ctx.Value(zonePickKey{}).(*zonePick); ok {
+		pick.done()
+		counter := &t.state.remoteInflight
+		if p.b.zone != "" && zone == p.b.zone {
+			counter = &t.state.localInflight
+		}
+		counter.Add(1)
+		pick.counter = counter
+	}
+	return ins
+}
+
+// overloaded 本可用区平均在途请求数超过其他可用区的 OverloadRatio 倍时视为过载
+func (p *zonePicker) overloaded() bool {
+	t := p.table
+	if p.b.overload < 0 || len(t.remote) == 0 {
+		return false
+	}
+	localAvg := float64(t.state.localInflight.Load()) / float64(len(t.local))
+	remoteAvg := float64(t.state.remoteInflight.Load()) / float64(len(t.remote))
+	if remoteAvg < 1 {
+		remoteAvg = 1
+	}
+	return localAvg > p.b.overload*remoteAvg
+}
@@ -3,18 +3,36 @@ package consts
 import "time"
 
 const (
-	Admin           = "Admin"
-	User            = "User"
-	ThirtyDays      = time.Hour * 24 * 30
-	AccountID       = "accountID"
+	Admin      = "Admin"
+	User       = "User"
+	ThirtyDays = time.Hour * 24 * 30
+
+	// AccountID 账户ID常量
+	//
+	// Deprecated: 使用 ctxx.AccountKey
+	AccountID = "accountID"
+
 	From            = "x-from"
 	ID              = "id"
 	Language        = "language"
 	DefaultLanguage = "zh"
-	UserID          = "user_id"     // 用户ID常量
-	TenantID        = "tenant_id"   // 租户ID常量
-	TraceID         = "traceID"     // 追踪ID常量
-	MerchantID      = "merchant_id" // 商户ID常量
+
+	// UserID 用户ID常量
+	//
+	// Deprecated: 使用 ctxx.UserKey
+	UserID = "user_id"
+
+	// TenantID 租户ID常量
+	//
+	// Deprecated: 使用 ctxx.TenantKey
+	TenantID = "tenant_id"
+
+	// TraceID 追踪ID常量
+	//
+	// Deprecated: 使用 ctxx.TraceKey
+	TraceID = "traceID"
+
+	MerchantID = "merchant_id" // 商户ID常量
 
 	HlogFilePath = "./tmp/hlog/logs/"
 	KlogFilePath = "./tmp/klog/logs/"
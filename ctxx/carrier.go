@@ -0,0 +1,40 @@
+package ctxx
+
+import (
+	"context"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// carrierPropagator 消息头中链路信息的格式，使用 W3C traceparent/tracestate
+var carrierPropagator = propagation.TraceContext{}
+
+// MarshalCarrier 将所有沿调用链传递的 ctxx 键和 W3C traceparent 编码为消息头，用于跨消息队列传递上下文
+func MarshalCarrier(ctx context.Context) map[string]string {
+	headers := make(map[string]string)
+	if ctx == nil {
+		return headers
+	}
+	for _, key := range PropagatedKeys() {
+		if value := GetMetaInfo(ctx, key); value != "" {
+			headers[key] = value
+		}
+	}
+	carrierPropagator.Inject(ctx, propagation.MapCarrier(headers))
+	return headers
+}
+
+// FromCarrier 从消息头恢复 ctxx 键和链路信息，键以持久化 metainfo 存储，消费者发起的 RPC 会继续传递
+// 消费者创建的 span 会成为生产者 span 的子 span
+func FromCarrier(ctx context.Context, headers map[string]string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, key := range PropagatedKeys() {
+		if value := headers[key]; value != "" {
+			ctx = metainfo.WithPersistentValue(ctx, key, value)
+		}
+	}
+	return carrierPropagator.Extract(ctx, propagation.MapCarrier(headers))
+}
@@ -26,6 +26,19 @@ const (
 	UserAgentKey           = "user_agent"
 	TenantTypeKey          = "tenant_type"
 	SkipDesensitizationKey = "skip_desensitization"
+	// TraceKey 链路追踪 ID，由元数据传递中间件从当前 span 复制
+	TraceKey = "trace_id"
+	// AccountKey 账户 ID，兼容 tools 使用的旧键名 accountID
+	AccountKey = "account_id"
+	// LanguageKey 语言，未设置时为 DefaultLanguage
+	LanguageKey = "language"
+	// LaneKey 泳道，使用持久化 metainfo 存储，会沿调用链一直传递
+	LaneKey = "lane"
+	// PriorityKey 请求优先级，使用持久化 metainfo 存储，服务过载时优先丢弃低优先级请求
+	PriorityKey = "priority"
+
+	// DefaultLanguage 默认语言
+	DefaultLanguage = "zh"
 
 	// app type
 	AppMerchant = "merchant"
@@ -35,9 +48,21 @@ const (
 	AppPlatform = "platform"
 	AppCallback = "callback"
 
+	// request priority，未设置时按 PriorityNormal 处理
+	PriorityCritical = "critical"
+	PriorityHigh     = "high"
+	PriorityNormal   = "normal"
+	PriorityLow      = "low"
+
 	// tenant type
 	// 平台版
 	TenantTypePlatform = "PLATFORM"
 	// 商户版
 	TenantTypeMerchant = "MERCHANT"
 )
+
+// tools 包使用的旧键名，读取时作为对应键的兼容键名
+const (
+	legacyTraceKey   = "traceID"
+	legacyAccountKey = "accountID"
+)
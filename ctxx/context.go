@@ -8,24 +8,42 @@ import (
 
 // Define key types for context name values
 
-// SetMetaInfo 设置 metainfo 值，同时设置到 context 和 metainfo 中
+// SetMetaInfo 设置 metainfo 值，同时设置到 context 和 metainfo 中，旧键名会被转换为键名
 func SetMetaInfo(ctx context.Context, key string, value string) context.Context {
-	return metainfo.WithValue(ctx, key, value)
+	return metainfo.WithValue(ctx, CanonicalKey(key), value)
 }
 
-// GetMetaInfo 获取 metainfo 值
+// GetMetaInfo 获取 metainfo 值，先查找临时值，再查找持久化值
+// key 可以是已注册的旧键名，键名没有值时会继续查找旧键名
 func GetMetaInfo(ctx context.Context, key string) string {
 	if ctx == nil {
 		return ""
 	}
 
-	if value, ok := metainfo.GetValue(ctx, key); ok && value != "" {
+	name, aliases := resolveKey(key)
+	if value, ok := lookupMetaInfo(ctx, name); ok {
 		return value
 	}
+	for _, alias := range aliases {
+		if value, ok := lookupMetaInfo(ctx, alias); ok {
+			return value
+		}
+	}
 
 	return ""
 }
 
+// lookupMetaInfo 查找非空的临时值或持久化值，经过 Promote 的键在下游各跳都只能通过持久化值读取
+func lookupMetaInfo(ctx context.Context, key string) (string, bool) {
+	if value, ok := metainfo.GetValue(ctx, key); ok && value != "" {
+		return value, true
+	}
+	if value, ok := metainfo.GetPersistentValue(ctx, key); ok && value != "" {
+		return value, true
+	}
+	return "", false
+}
+
 // GetMetaInfoWithFallback 获取 metainfo 值，支持自定义 fallback 键名
 func GetMetaInfoWithFallback(ctx context.Context, primaryKey string, fallbackKeys ...string) string {
 	if ctx == nil {
@@ -33,13 +51,13 @@ func GetMetaInfoWithFallback(ctx context.Context, primaryKey string, fallbackKey
 	}
 
 	// 首先尝试主键
-	if value, ok := metainfo.GetValue(ctx, primaryKey); ok && value != "" {
+	if value, ok := lookupMetaInfo(ctx, primaryKey); ok {
 		return value
 	}
 
 	// 尝试 fallback 键名
 	for _, fallbackKey := range fallbackKeys {
-		if value, ok := metainfo.GetValue(ctx, fallbackKey); ok && value != "" {
+		if value, ok := lookupMetaInfo(ctx, fallbackKey); ok {
 			return value
 		}
 	}
@@ -54,7 +72,7 @@ func WithTenantID(ctx context.Context, tenantID string) context.Context {
 
 // GetTenantID retrieves tenant ID from the context
 func GetTenantID(ctx context.Context) string {
-	return GetMetaInfo(ctx, TenantKey)
+	return GetString(ctx, TenantKey)
 }
 
 // WithUserID adds user ID to the context
@@ -64,7 +82,7 @@ func WithUserID(ctx context.Context, userID string) context.Context {
 
 // GetUserID retrieves user ID from the context
 func GetUserID(ctx context.Context) string {
-	return GetMetaInfo(ctx, UserKey)
+	return GetString(ctx, UserKey)
 }
 
 // WithRequestID adds request ID to the context
@@ -74,7 +92,7 @@ func WithRequestID(ctx context.Context, requestID string) context.Context {
 
 // GetRequestID retrieves request ID from the context
 func GetRequestID(ctx context.Context) string {
-	return GetMetaInfo(ctx, RequestKey)
+	return GetString(ctx, RequestKey)
 }
 
 // WithMerchantID adds merchant ID to the context
@@ -84,7 +102,7 @@ func WithMerchantID(ctx context.Context, merchantID string) context.Context {
 
 // GetMerchantID retrieves merchant ID from the context
 func GetMerchantID(ctx context.Context) string {
-	return GetMetaInfo(ctx, MerchantKey)
+	return GetString(ctx, MerchantKey)
 }
 
 // WithMemberID adds member ID to the context
@@ -94,7 +112,7 @@ func WithMemberID(ctx context.Context, memberID string) context.Context {
 
 // GetMemberID retrieves member ID from the context
 func GetMemberID(ctx context.Context) string {
-	return GetMetaInfo(ctx, MemberKey)
+	return GetString(ctx, MemberKey)
 }
 
 // WithDonorID adds donor ID to the context
@@ -104,7 +122,7 @@ func WithDonorID(ctx context.Context, donorID string) context.Context {
 
 // GetDonorID retrieves donor ID from the context
 func GetDonorID(ctx context.Context) string {
-	return GetMetaInfo(ctx, DonorKey)
+	return GetString(ctx, DonorKey)
 }
 
 // WithAppType adds app type to the context
@@ -114,56 +132,97 @@ func WithAppType(ctx context.Context, appType string) context.Context {
 
 // GetAppType retrieves app type from the context
 func GetAppType(ctx context.Context) string {
-	return GetMetaInfo(ctx, AppTypeKey)
+	return GetString(ctx, AppTypeKey)
 }
 
-// WithTenantIsolation enables or disables tenant isolation for the context
-func WithTenantIsolation(ctx context.Context, enabled bool) context.Context {
-	enabledStr := "true"
-	if !enabled {
-		enabledStr = "false"
-	}
-	return metainfo.WithValue(ctx, TenantIsolationKey, enabledStr)
+// WithLane 设置泳道，泳道使用持久化 metainfo 存储，会沿调用链传递给所有下游服务
+func WithLane(ctx context.Context, lane string) context.Context {
+	return metainfo.WithPersistentValue(ctx, LaneKey, lane)
 }
 
-// IsTenantIsolationEnabled checks if tenant isolation is enabled for the context
-func IsTenantIsolationEnabled(ctx context.Context) bool {
+// GetLane 获取泳道
+func GetLane(ctx context.Context) string {
 	if ctx == nil {
-		return true // 默认启用租户隔离
+		return ""
 	}
+	if value, ok := metainfo.GetPersistentValue(ctx, LaneKey); ok {
+		return value
+	}
+	return ""
+}
 
-	value := GetMetaInfo(ctx, TenantIsolationKey)
-	if value == "false" {
-		return false
+// WithPriority 设置请求优先级，优先级使用持久化 metainfo 存储，会沿调用链传递给所有下游服务
+func WithPriority(ctx context.Context, priority string) context.Context {
+	return metainfo.WithPersistentValue(ctx, PriorityKey, priority)
+}
+
+// GetPriority 获取请求优先级，未设置时返回 PriorityNormal
+func GetPriority(ctx context.Context) string {
+	if ctx == nil {
+		return PriorityNormal
 	}
+	if value, ok := metainfo.GetPersistentValue(ctx, PriorityKey); ok && value != "" {
+		return value
+	}
+	return PriorityNormal
+}
+
+// WithAccountID 设置账户 ID
+func WithAccountID(ctx context.Context, accountID string) context.Context {
+	return SetMetaInfo(ctx, AccountKey, accountID)
+}
+
+// GetAccountID 获取账户 ID，兼容旧键名 accountID
+func GetAccountID(ctx context.Context) string {
+	return GetString(ctx, AccountKey)
+}
 
-	return true // 默认启用租户隔离
+// WithLanguage 设置语言
+func WithLanguage(ctx context.Context, language string) context.Context {
+	return SetMetaInfo(ctx, LanguageKey, language)
+}
+
+// GetLanguage 获取语言，未设置时返回 DefaultLanguage
+func GetLanguage(ctx context.Context) string {
+	return GetString(ctx, LanguageKey)
+}
+
+// isolationKey 隔离开关在 context 中的键，只能在本进程内通过 WithTenantIsolation 和 WithMerchantIsolation 设置，
+// RPC metainfo 和消息头都无法写入
+type isolationKey string
+
+// WithTenantIsolation enables or disables tenant isolation for the context
+// 开关只在本进程内生效，不会传给下游服务
+func WithTenantIsolation(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, isolationKey(TenantIsolationKey), enabled)
+}
+
+// IsTenantIsolationEnabled checks if tenant isolation is enabled for the context
+// 默认启用租户隔离
+func IsTenantIsolationEnabled(ctx context.Context) bool {
+	return isolationEnabled(ctx, TenantIsolationKey)
 }
 
 // WithMerchantIsolation enables or disables merchant isolation for the context
+// 开关只在本进程内生效，不会传给下游服务
 func WithMerchantIsolation(ctx context.Context, enabled bool) context.Context {
-	enabledStr := "true"
-	if !enabled {
-		enabledStr = "false"
-	}
-	return metainfo.WithValue(ctx, MerchantIsolationKey, enabledStr)
+	return context.WithValue(ctx, isolationKey(MerchantIsolationKey), enabled)
 }
 
 // IsMerchantIsolationEnabled checks if merchant isolation is enabled for the context
+// 默认启用商户隔离
 func IsMerchantIsolationEnabled(ctx context.Context) bool {
-	if ctx == nil {
-		return true // 默认启用商户隔离
-	}
-
-	value := GetMetaInfo(ctx, MerchantIsolationKey)
-	if value == "false" {
-		return false
-	}
+	return isolationEnabled(ctx, MerchantIsolationKey)
+}
 
-	return true // 默认启用商户隔离
+// isolationEnabled 读取本地设置的隔离开关，没有设置时启用
+func isolationEnabled(ctx context.Context, key string) bool {
+	enabled, ok := Value[bool](ctx, isolationKey(key))
+	return !ok || enabled
 }
 
 // GetContextInfo retrieves all context information
+// Values 包含所有已注册键（包括服务自定义的键）的值
 func GetContextInfo(ctx context.Context) *ContextInfo {
 	return &ContextInfo{
 		TenantID:            GetTenantID(ctx),
@@ -179,29 +238,25 @@ func GetContextInfo(ctx context.Context) *ContextInfo {
 		MemberName:          GetMemberName(ctx),
 		DonorName:           GetDonorName(ctx),
 		AppName:             GetAppName(ctx),
-		ExpandedInfo:        GetMetaInfo(ctx, ExpandedKey),
-		AppId:               GetMetaInfo(ctx, AppIdKey),
-		Ip:                  GetMetaInfo(ctx, IpKey),
+		ExpandedInfo:        GetString(ctx, ExpandedKey),
+		AppId:               GetAppId(ctx),
+		Ip:                  GetIp(ctx),
+		TenantType:          GetTenantType(ctx),
+		TraceID:             GetTraceID(ctx),
+		Lane:                GetLane(ctx),
 		SkipDesensitization: IsSkipDesensitizationEnabled(ctx),
+		Values:              GetAllMetaInfo(ctx),
 	}
 }
 
-// GetAllMetaInfo 获取所有 metainfo 信息
+// GetAllMetaInfo 获取所有已注册键的 metainfo 信息，不包含未设置的键
 func GetAllMetaInfo(ctx context.Context) map[string]string {
 	result := make(map[string]string)
-
-	// 获取所有预定义的键（包括 ID、Name 和 UserAgent）
-	keys := []string{
-		TenantKey, UserKey, RequestKey, MerchantKey, MemberKey, DonorKey, AppTypeKey,
-		TenantNameKey, UserNameKey, MerchantNameKey, MemberNameKey, DonorNameKey, AppNameKey,
-		UserAgentKey,
-	}
-	for _, key := range keys {
-		if value := GetMetaInfo(ctx, key); value != "" {
-			result[key] = value
+	for _, spec := range RegisteredKeys() {
+		if value := GetMetaInfo(ctx, spec.Name); value != "" {
+			result[spec.Name] = value
 		}
 	}
-
 	return result
 }
 
@@ -213,16 +268,23 @@ func SetMultipleMetaInfo(ctx context.Context, values map[string]string) context.
 	return ctx
 }
 
-// CopyMetaInfo 从源 context 复制 metainfo 到目标 context
+// CopyMetaInfo 从源 context 复制所有已注册键的 metainfo 到目标 context，临时值和持久化值分别保留，
+// 本地设置的隔离开关同样会被复制
 func CopyMetaInfo(fromCtx, toCtx context.Context) context.Context {
-	keys := []string{
-		TenantKey, UserKey, RequestKey, MerchantKey, MemberKey, DonorKey, AppTypeKey,
-		TenantNameKey, UserNameKey, MerchantNameKey, MemberNameKey, DonorNameKey, AppNameKey,
-		UserAgentKey,
+	if fromCtx == nil {
+		return toCtx
+	}
+	for _, key := range []string{TenantIsolationKey, MerchantIsolationKey} {
+		if enabled, ok := Value[bool](fromCtx, isolationKey(key)); ok {
+			toCtx = context.WithValue(toCtx, isolationKey(key), enabled)
+		}
 	}
-	for _, key := range keys {
-		if value := GetMetaInfo(fromCtx, key); value != "" {
-			toCtx = SetMetaInfo(toCtx, key, value)
+	for _, spec := range RegisteredKeys() {
+		if value, ok := metainfo.GetValue(fromCtx, spec.Name); ok && value != "" {
+			toCtx = SetMetaInfo(toCtx, spec.Name, value)
+		}
+		if value, ok := metainfo.GetPersistentValue(fromCtx, spec.Name); ok && value != "" {
+			toCtx = metainfo.WithPersistentValue(toCtx, spec.Name, value)
 		}
 	}
 	return toCtx
@@ -248,7 +310,7 @@ func WithTenantName(ctx context.Context, tenantName string) context.Context {
 
 // GetTenantName retrieves tenant name from the context
 func GetTenantName(ctx context.Context) string {
-	return GetMetaInfo(ctx, TenantNameKey)
+	return GetString(ctx, TenantNameKey)
 }
 
 // WithMemberName adds member name to the context
@@ -258,7 +320,7 @@ func WithMemberName(ctx context.Context, memberName string) context.Context {
 
 // GetMemberName retrieves member name from the context
 func GetMemberName(ctx context.Context) string {
-	return GetMetaInfo(ctx, MemberNameKey)
+	return GetString(ctx, MemberNameKey)
 }
 
 // WithMerchantName adds merchant name to the context
@@ -268,7 +330,7 @@ func WithMerchantName(ctx context.Context, merchantName string) context.Context
 
 // GetMerchantName retrieves merchant name from the context
 func GetMerchantName(ctx context.Context) string {
-	return GetMetaInfo(ctx, MerchantNameKey)
+	return GetString(ctx, MerchantNameKey)
 }
 
 // WithUserName adds user name to the context
@@ -278,7 +340,7 @@ func WithUserName(ctx context.Context, userName string) context.Context {
 
 // GetUserName retrieves user name from the context
 func GetUserName(ctx context.Context) string {
-	return GetMetaInfo(ctx, UserNameKey)
+	return GetString(ctx, UserNameKey)
 }
 
 // WithDonorName adds donor name to the context
@@ -288,7 +350,7 @@ func WithDonorName(ctx context.Context, donorName string) context.Context {
 
 // GetDonorName retrieves donor name from the context
 func GetDonorName(ctx context.Context) string {
-	return GetMetaInfo(ctx, DonorNameKey)
+	return GetString(ctx, DonorNameKey)
 }
 
 // WithAppName adds app name to the context
@@ -298,7 +360,7 @@ func WithAppName(ctx context.Context, appName string) context.Context {
 
 // GetAppName retrieves app name from the context
 func GetAppName(ctx context.Context) string {
-	return GetMetaInfo(ctx, AppNameKey)
+	return GetString(ctx, AppNameKey)
 }
 
 // WithUserAgent adds user agent to the context
@@ -308,7 +370,7 @@ func WithUserAgent(ctx context.Context, userAgent string) context.Context {
 
 // GetUserAgent retrieves user agent from the context
 func GetUserAgent(ctx context.Context) string {
-	return GetMetaInfo(ctx, UserAgentKey)
+	return GetString(ctx, UserAgentKey)
 }
 
 // WithExpandedInfo adds expanded info to the context
@@ -328,7 +390,7 @@ func WithAppId(ctx context.Context, appId string) context.Context {
 
 // GetAppId retrieves app id from the context
 func GetAppId(ctx context.Context) string {
-	return GetMetaInfo(ctx, AppIdKey)
+	return GetString(ctx, AppIdKey)
 }
 
 // WithIp adds ip to the context
@@ -338,7 +400,7 @@ func WithIp(ctx context.Context, ip string) context.Context {
 
 // GetIp retrieves ip from the context
 func GetIp(ctx context.Context) string {
-	return GetMetaInfo(ctx, IpKey)
+	return GetString(ctx, IpKey)
 }
 
 // WithTenantType adds tenant type to the context
@@ -348,28 +410,16 @@ func WithTenantType(ctx context.Context, tenantType string) context.Context {
 
 // GetTenantType 获取租户类型
 func GetTenantType(ctx context.Context) string {
-	return GetMetaInfo(ctx, TenantTypeKey)
+	return GetString(ctx, TenantTypeKey)
 }
 
 // WithSkipDesensitization enables or disables skipping desensitization for the context
 func WithSkipDesensitization(ctx context.Context, skip bool) context.Context {
-	skipStr := "true"
-	if !skip {
-		skipStr = "false"
-	}
-	return SetMetaInfo(ctx, SkipDesensitizationKey, skipStr)
+	return WithBool(ctx, SkipDesensitizationKey, skip)
 }
 
 // IsSkipDesensitizationEnabled checks if desensitization should be skipped for the context
+// 默认不跳过脱敏（即启用脱敏）
 func IsSkipDesensitizationEnabled(ctx context.Context) bool {
-	if ctx == nil {
-		return false // 默认不跳过脱敏（即启用脱敏）
-	}
-
-	value := GetMetaInfo(ctx, SkipDesensitizationKey)
-	if value == "true" {
-		return true
-	}
-
-	return false // 默认不跳过脱敏（即启用脱敏）
+	return GetBool(ctx, SkipDesensitizationKey)
 }
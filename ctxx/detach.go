@@ -0,0 +1,66 @@
+package ctxx
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// detachTracerName 后台任务 span 使用的 tracer 名称
+const detachTracerName = "github.com/grayscalecloud/kitexcommon/ctxx"
+
+// goSpanName ctxx.Go 创建的 span 名称
+const goSpanName = "ctxx.Go"
+
+// parentLinkKey 分离前的 span 在 context 中的键
+type parentLinkKey struct{}
+
+// Detach 返回与 ctx 的取消和截止时间无关的新 context，保留所有已注册的 metainfo 键，
+// 并记录原 span 供后台任务通过 SpanLink 建立链接，适合在 RPC 处理函数中启动后台 goroutine
+func Detach(ctx context.Context) context.Context {
+	detached := CopyMetaInfo(ctx, context.Background())
+	if ctx == nil {
+		return detached
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		detached = context.WithValue(detached, parentLinkKey{}, sc)
+	}
+	return detached
+}
+
+// SpanLink 返回分离前的 span 链接，ctx 不是由 Detach 创建或原 context 没有 span 时返回 false
+func SpanLink(ctx context.Context) (trace.Link, bool) {
+	sc, ok := ctx.Value(parentLinkKey{}).(trace.SpanContext)
+	if !ok {
+		return trace.Link{}, false
+	}
+	return trace.Link{SpanContext: sc}, true
+}
+
+// Go 在新的 goroutine 中以分离后的 context 执行 fn，fn 在链接到原 span 的新 span 中运行，
+// panic 会被恢复并连同租户 ID 和请求 ID 一起记录
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	detached := Detach(ctx)
+	go func() {
+		opts := []trace.SpanStartOption{trace.WithNewRoot()}
+		if link, ok := SpanLink(detached); ok {
+			opts = append(opts, trace.WithLinks(link))
+		}
+		spanCtx, span := otel.Tracer(detachTracerName).Start(detached, goSpanName, opts...)
+		defer span.End()
+		defer Recover(spanCtx)
+
+		fn(spanCtx)
+	}()
+}
+
+// Recover 恢复 panic 并连同租户 ID 和请求 ID 一起记录，需要通过 defer 调用
+func Recover(ctx context.Context) {
+	if r := recover(); r != nil {
+		klog.CtxErrorf(ctx, "后台任务 panic: tenant_id=%s request_id=%s: %v\n%s",
+			GetTenantID(ctx), GetRequestID(ctx), r, debug.Stack())
+	}
+}
@@ -18,5 +18,10 @@ type ContextInfo struct {
 	ExpandedInfo        string
 	AppId               string
 	Ip                  string
+	TenantType          string
+	TraceID             string
+	Lane                string
 	SkipDesensitization bool
+	// Values 所有已注册键的原始值，包括服务通过 RegisterKey 注册的键
+	Values map[string]string
 }
@@ -0,0 +1,82 @@
+package ctxx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Promote 将临时 metainfo 值提升为持久化值，已有相同持久化值的键不重复设置
+// 临时 metainfo 只会传给直接下游，提升后整条调用链上的服务都能读取
+func Promote(ctx context.Context, keys ...string) context.Context {
+	for _, key := range keys {
+		value, ok := metainfo.GetValue(ctx, key)
+		if !ok || value == "" {
+			continue
+		}
+		if current, ok := metainfo.GetPersistentValue(ctx, key); ok && current == value {
+			continue
+		}
+		ctx = metainfo.WithPersistentValue(ctx, key, value)
+	}
+	return ctx
+}
+
+// StripLocal 删除 LocalKeys 中各键的临时值和持久化值
+// 服务端在处理请求前调用，丢弃上游传来的隔离开关等值，客户端在发起请求前调用，避免本地设置的值传给下游
+func StripLocal(ctx context.Context) context.Context {
+	for _, key := range LocalKeys() {
+		// 上游传来的值和本地设置的临时值分开保存，每次只删除其中一个
+		for i := 0; i < 2; i++ {
+			if _, ok := metainfo.GetValue(ctx, key); ok {
+				ctx = metainfo.DelValue(ctx, key)
+			}
+		}
+		if _, ok := metainfo.GetPersistentValue(ctx, key); ok {
+			ctx = metainfo.DelPersistentValue(ctx, key)
+		}
+	}
+	return ctx
+}
+
+// NewRequestID 生成 32 位十六进制请求 ID
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// EnsureRequestID 请求 ID 不存在时生成一个并以持久化 metainfo 存储，返回请求 ID
+func EnsureRequestID(ctx context.Context) (context.Context, string) {
+	if id := GetRequestID(ctx); id != "" {
+		return ctx, id
+	}
+	id := NewRequestID()
+	return metainfo.WithPersistentValue(ctx, RequestKey, id), id
+}
+
+// WithTraceID 设置链路追踪 ID，使用持久化 metainfo 存储
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return metainfo.WithPersistentValue(ctx, TraceKey, traceID)
+}
+
+// GetTraceID 获取链路追踪 ID
+func GetTraceID(ctx context.Context) string {
+	return GetMetaInfo(ctx, TraceKey)
+}
+
+// WithSpanTraceID 将当前 span 的追踪 ID 复制到 metainfo，没有有效 span 时不做修改
+func WithSpanTraceID(ctx context.Context) context.Context {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ctx
+	}
+	traceID := sc.TraceID().String()
+	if GetTraceID(ctx) == traceID {
+		return ctx
+	}
+	return WithTraceID(ctx, traceID)
+}
@@ -0,0 +1,71 @@
+package ctxx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+)
+
+func TestLocalKeysNotPropagated(t *testing.T) {
+	local := map[string]bool{}
+	for _, key := range LocalKeys() {
+		local[key] = true
+	}
+	for _, key := range []string{TenantIsolationKey, MerchantIsolationKey, SkipDesensitizationKey} {
+		if !local[key] {
+			t.Fatalf("%s 应只能在本地设置", key)
+		}
+	}
+	for _, key := range PropagatedKeys() {
+		if local[key] {
+			t.Fatalf("只能本地设置的键 %s 不应沿调用链传递", key)
+		}
+	}
+
+	if err := RegisterKey(KeySpec{Name: "test_local_propagate", Propagate: true, Local: true}); err == nil {
+		t.Fatalf("同时设置 Propagate 和 Local 应返回错误")
+	}
+}
+
+func TestStripLocal(t *testing.T) {
+	// 模拟上游传来的临时值和持久化值
+	ctx := metainfo.WithValue(context.Background(), TenantIsolationKey, "false")
+	ctx = metainfo.TransferForward(ctx)
+	ctx = metainfo.WithValue(ctx, TenantIsolationKey, "false")
+	ctx = metainfo.WithPersistentValue(ctx, SkipDesensitizationKey, "true")
+	ctx = metainfo.WithPersistentValue(ctx, MerchantIsolationKey, "false")
+	ctx = WithTenantID(ctx, "t1")
+
+	ctx = StripLocal(ctx)
+	for _, key := range LocalKeys() {
+		if HasMetaInfo(ctx, key) {
+			t.Fatalf("StripLocal 后不应再有 %s 的值", key)
+		}
+	}
+	if !IsTenantIsolationEnabled(ctx) || !IsMerchantIsolationEnabled(ctx) || IsSkipDesensitizationEnabled(ctx) {
+		t.Fatalf("丢弃上游的值后隔离开关应恢复默认值")
+	}
+	if GetTenantID(ctx) != "t1" {
+		t.Fatalf("StripLocal 不应影响其他键")
+	}
+}
+
+func TestCarrierSkipsLocalKeys(t *testing.T) {
+	ctx := WithTenantID(context.Background(), "t1")
+	ctx = metainfo.WithPersistentValue(ctx, TenantIsolationKey, "false")
+
+	headers := MarshalCarrier(ctx)
+	if _, ok := headers[TenantIsolationKey]; ok {
+		t.Fatalf("消息头中不应包含只能本地设置的键")
+	}
+
+	headers[TenantIsolationKey] = "false"
+	consumed := FromCarrier(context.Background(), headers)
+	if !IsTenantIsolationEnabled(consumed) {
+		t.Fatalf("不应从消息头恢复只能本地设置的键")
+	}
+	if GetTenantID(consumed) != "t1" {
+		t.Fatalf("应从消息头恢复租户 ID")
+	}
+}
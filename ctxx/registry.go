@@ -0,0 +1,302 @@
+package ctxx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// KeyType 上下文键的值类型，值在 metainfo 中都以字符串存储
+type KeyType int
+
+const (
+	KeyString KeyType = iota
+	KeyBool
+	KeyInt
+	KeyJSON
+)
+
+// String 返回类型名称
+func (t KeyType) String() string {
+	switch t {
+	case KeyString:
+		return "string"
+	case KeyBool:
+		return "bool"
+	case KeyInt:
+		return "int"
+	case KeyJSON:
+		return "json"
+	default:
+		return fmt.Sprintf("KeyType(%d)", int(t))
+	}
+}
+
+// KeySpec 上下文键的定义
+type KeySpec struct {
+	// Name 键名，即 metainfo 中的键
+	Name string
+	// Type 值类型
+	Type KeyType
+	// Default 未设置时的默认值，按 Type 的字符串形式填写
+	Default string
+	// Propagate 是否由元数据传递中间件提升为持久化值，沿调用链一直传递
+	Propagate bool
+	// Local 是否只能在本进程内设置，元数据传递中间件会丢弃上游传来的值，也不会传给下游，
+	// 用于隔离开关等不能由调用方控制的键
+	Local bool
+	// Sensitive 是否为敏感信息，MaskedMetaInfo 中会被遮盖
+	Sensitive bool
+	// Aliases 兼容的旧键名，读取时在键名没有值时依次查找
+	Aliases []string
+}
+
+// sensitiveMask 敏感信息的遮盖值
+const sensitiveMask = "***"
+
+// keyRegistry 已注册的上下文键，按注册顺序保存
+var keyRegistry = struct {
+	mu      sync.RWMutex
+	specs   map[string]KeySpec
+	aliases map[string]string // 旧键名到键名的映射
+	order   []string
+}{specs: make(map[string]KeySpec), aliases: make(map[string]string)}
+
+func init() {
+	for _, spec := range []KeySpec{
+		{Name: TenantKey, Propagate: true},
+		{Name: UserKey, Propagate: true},
+		{Name: RequestKey, Propagate: true},
+		{Name: MerchantKey, Propagate: true},
+		{Name: MemberKey, Propagate: true},
+		{Name: DonorKey, Propagate: true},
+		{Name: AppTypeKey, Propagate: true},
+		{Name: TenantNameKey, Propagate: true},
+		{Name: UserNameKey, Propagate: true, Sensitive: true},
+		{Name: MerchantNameKey, Propagate: true},
+		{Name: MemberNameKey, Propagate: true, Sensitive: true},
+		{Name: DonorNameKey, Propagate: true, Sensitive: true},
+		{Name: AppNameKey, Propagate: true},
+		{Name: AppIdKey, Propagate: true},
+		{Name: IpKey, Propagate: true, Sensitive: true},
+		{Name: UserAgentKey, Propagate: true},
+		{Name: TenantTypeKey, Propagate: true},
+		{Name: ExpandedKey, Type: KeyJSON, Propagate: true},
+		{Name: TenantIsolationKey, Type: KeyBool, Default: "true", Local: true},
+		{Name: MerchantIsolationKey, Type: KeyBool, Default: "true", Local: true},
+		{Name: SkipDesensitizationKey, Type: KeyBool, Default: "false", Local: true},
+		{Name: TraceKey, Propagate: true, Aliases: []string{legacyTraceKey}},
+		{Name: LaneKey, Propagate: true},
+		{Name: PriorityKey, Default: PriorityNormal, Propagate: true},
+		{Name: AccountKey, Propagate: true, Aliases: []string{legacyAccountKey}},
+		{Name: LanguageKey, Default: DefaultLanguage, Propagate: true},
+	} {
+		MustRegisterKey(spec)
+	}
+}
+
+// RegisterKey 注册上下文键，服务可以注册自己的键，注册后由 GetAllMetaInfo、CopyMetaInfo 和元数据传递中间件统一处理
+func RegisterKey(spec KeySpec) error {
+	if spec.Name == "" {
+		return fmt.Errorf("上下文键名不能为空")
+	}
+	if spec.Propagate && spec.Local {
+		return fmt.Errorf("上下文键 %s 不能同时设置 Propagate 和 Local", spec.Name)
+	}
+	if spec.Default != "" {
+		if err := spec.validate(spec.Default); err != nil {
+			return fmt.Errorf("上下文键 %s 的默认值无效: %w", spec.Name, err)
+		}
+	}
+
+	keyRegistry.mu.Lock()
+	defer keyRegistry.mu.Unlock()
+	for _, name := range append([]string{spec.Name}, spec.Aliases...) {
+		if _, ok := keyRegistry.specs[name]; ok {
+			return fmt.Errorf("上下文键 %s 已注册", name)
+		}
+		if _, ok := keyRegistry.aliases[name]; ok {
+			return fmt.Errorf("上下文键 %s 已注册为旧键名", name)
+		}
+	}
+	keyRegistry.specs[spec.Name] = spec
+	for _, alias := range spec.Aliases {
+		keyRegistry.aliases[alias] = spec.Name
+	}
+	keyRegistry.order = append(keyRegistry.order, spec.Name)
+	return nil
+}
+
+// MustRegisterKey 注册上下文键，失败时 panic，适合在 init 中使用
+func MustRegisterKey(spec KeySpec) {
+	if err := RegisterKey(spec); err != nil {
+		panic(err)
+	}
+}
+
+// LookupKey 查找上下文键的定义
+func LookupKey(name string) (KeySpec, bool) {
+	keyRegistry.mu.RLock()
+	defer keyRegistry.mu.RUnlock()
+	spec, ok := keyRegistry.specs[name]
+	return spec, ok
+}
+
+// CanonicalKey 返回旧键名对应的键名，不是旧键名时原样返回
+func CanonicalKey(name string) string {
+	keyRegistry.mu.RLock()
+	defer keyRegistry.mu.RUnlock()
+	if canonical, ok := keyRegistry.aliases[name]; ok {
+		return canonical
+	}
+	return name
+}
+
+// resolveKey 返回键名及其旧键名
+func resolveKey(name string) (string, []string) {
+	keyRegistry.mu.RLock()
+	defer keyRegistry.mu.RUnlock()
+	if canonical, ok := keyRegistry.aliases[name]; ok {
+		name = canonical
+	}
+	return name, keyRegistry.specs[name].Aliases
+}
+
+// RegisteredKeys 按注册顺序返回所有上下文键的定义
+func RegisteredKeys() []KeySpec {
+	keyRegistry.mu.RLock()
+	defer keyRegistry.mu.RUnlock()
+	specs := make([]KeySpec, 0, len(keyRegistry.order))
+	for _, name := range keyRegistry.order {
+		specs = append(specs, keyRegistry.specs[name])
+	}
+	return specs
+}
+
+// PropagatedKeys 返回需要沿调用链传递的键名
+func PropagatedKeys() []string {
+	var keys []string
+	for _, spec := range RegisteredKeys() {
+		if spec.Propagate {
+			keys = append(keys, spec.Name)
+		}
+	}
+	return keys
+}
+
+// LocalKeys 返回只能在本进程内设置的键名
+func LocalKeys() []string {
+	var keys []string
+	for _, spec := range RegisteredKeys() {
+		if spec.Local {
+			keys = append(keys, spec.Name)
+		}
+	}
+	return keys
+}
+
+// validate 校验值能否按键的类型解析
+func (s KeySpec) validate(value string) error {
+	switch s.Type {
+	case KeyBool:
+		_, err := strconv.ParseBool(value)
+		return err
+	case KeyInt:
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err
+	case KeyJSON:
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("不是有效的 JSON")
+		}
+	}
+	return nil
+}
+
+// Value 从 context.Value 中读取指定类型的值，类型不符时返回 false 而不是 panic
+func Value[T any](ctx context.Context, key interface{}) (T, bool) {
+	var zero T
+	if ctx == nil {
+		return zero, false
+	}
+	v, ok := ctx.Value(key).(T)
+	if !ok {
+		return zero, false
+	}
+	return v, true
+}
+
+// GetString 获取字符串值，未设置时返回注册的默认值
+func GetString(ctx context.Context, key string) string {
+	if value := GetMetaInfo(ctx, key); value != "" {
+		return value
+	}
+	spec, _ := LookupKey(CanonicalKey(key))
+	return spec.Default
+}
+
+// GetBool 获取布尔值，未设置或无法解析时返回注册的默认值
+func GetBool(ctx context.Context, key string) bool {
+	if v, err := strconv.ParseBool(GetMetaInfo(ctx, key)); err == nil {
+		return v
+	}
+	spec, _ := LookupKey(CanonicalKey(key))
+	v, _ := strconv.ParseBool(spec.Default)
+	return v
+}
+
+// WithBool 设置布尔值
+func WithBool(ctx context.Context, key string, value bool) context.Context {
+	return SetMetaInfo(ctx, key, strconv.FormatBool(value))
+}
+
+// GetInt 获取整数值，未设置或无法解析时返回注册的默认值
+func GetInt(ctx context.Context, key string) int64 {
+	if v, err := strconv.ParseInt(GetMetaInfo(ctx, key), 10, 64); err == nil {
+		return v
+	}
+	spec, _ := LookupKey(CanonicalKey(key))
+	v, _ := strconv.ParseInt(spec.Default, 10, 64)
+	return v
+}
+
+// WithInt 设置整数值
+func WithInt(ctx context.Context, key string, value int64) context.Context {
+	return SetMetaInfo(ctx, key, strconv.FormatInt(value, 10))
+}
+
+// GetJSON 将 JSON 值解析到 v，未设置且没有默认值时不修改 v
+func GetJSON(ctx context.Context, key string, v interface{}) error {
+	value := GetString(ctx, key)
+	if value == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return fmt.Errorf("解析上下文键 %s 的 JSON 值失败: %w", key, err)
+	}
+	return nil
+}
+
+// WithJSON 将 v 序列化为 JSON 后设置
+func WithJSON(ctx context.Context, key string, v interface{}) (context.Context, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ctx, fmt.Errorf("序列化上下文键 %s 的值失败: %w", key, err)
+	}
+	return SetMetaInfo(ctx, key, string(data)), nil
+}
+
+// MaskedMetaInfo 获取所有已注册键的值，敏感信息被遮盖，适合写入日志
+func MaskedMetaInfo(ctx context.Context) map[string]string {
+	result := make(map[string]string)
+	for _, spec := range RegisteredKeys() {
+		if value := GetMetaInfo(ctx, spec.Name); value != "" {
+			if spec.Sensitive {
+				value = sensitiveMask
+			}
+			result[spec.Name] = value
+		}
+	}
+	return result
+}
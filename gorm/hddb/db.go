@@ -0,0 +1,246 @@
+package hddb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/grayscalecloud/kitexcommon/gorm/hdobserve"
+	"github.com/grayscalecloud/kitexcommon/hdmodel"
+	"github.com/grayscalecloud/kitexcommon/kvconfig"
+	"github.com/grayscalecloud/kitexcommon/lifecycle"
+	"gopkg.in/yaml.v2"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// 连接池默认值
+const (
+	DefaultMaxOpenConns    = 100
+	DefaultMaxIdleConns    = 10
+	DefaultConnMaxLifetime = time.Hour
+	DefaultConnMaxIdleTime = 10 * time.Minute
+	// DefaultPingTimeout 健康检查默认超时时间
+	DefaultPingTimeout = 5 * time.Second
+)
+
+// ComponentDatabase 注册到生命周期管理器的默认组件名称
+const ComponentDatabase = "database"
+
+// commonDataId 通用配置的 dataId
+const commonDataId = "common"
+
+// Options 数据库构建选项
+type Options struct {
+	// Name 生命周期组件名称，同一进程打开多个库时需要区分，默认 ComponentDatabase
+	Name string
+	// Dialector 根据 DSN 创建方言，默认 mysql.Open，本地测试可以换成 SQLite
+	Dialector func(dsn string) gorm.Dialector
+	// Config gorm 配置
+	Config *gorm.Config
+	// Policy 副本选择策略，默认随机
+	Policy dbresolver.Policy
+	// Plugins 额外注册的插件，如 hdtenant.New()
+	Plugins []gorm.Plugin
+	// PingTimeout 健康检查超时时间，默认 DefaultPingTimeout
+	PingTimeout time.Duration
+	// Lifecycle 注册关闭钩子的生命周期管理器，默认 lifecycle.Default()
+	Lifecycle *lifecycle.Lifecycle
+}
+
+// DB 带读写分离和连接池配置的数据库
+type DB struct {
+	*gorm.DB
+	// resolver 读写分离插件，没有副本时为 nil
+	resolver *dbresolver.DBResolver
+	// pingTimeout 健康检查超时时间
+	pingTimeout time.Duration
+
+	mu sync.Mutex
+	// cfg 当前生效的配置
+	cfg hdmodel.MySQL
+}
+
+// Open 根据 hdmodel.MySQL 打开数据库：配置副本时注册 dbresolver 读写分离，注册可观测性插件，
+// 按配置设置连接池并对主库和所有副本做健康检查，最后在生命周期管理器中注册关闭钩子
+func Open(ctx context.Context, cfg hdmodel.MySQL, opts *Options) (*DB, error) {
+	if cfg.DSN == "" {
+		return nil, fmt.Errorf("数据库 DSN 不能为空")
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.Name == "" {
+		opts.Name = ComponentDatabase
+	}
+	if opts.Dialector == nil {
+		opts.Dialector = mysql.Open
+	}
+	if opts.Config == nil {
+		opts.Config = &gorm.Config{}
+	}
+	if opts.Policy == nil {
+		opts.Policy = dbresolver.RandomPolicy{}
+	}
+	if opts.PingTimeout <= 0 {
+		opts.PingTimeout = DefaultPingTimeout
+	}
+	if opts.Lifecycle == nil {
+		opts.Lifecycle = lifecycle.Default()
+	}
+
+	gdb, err := gorm.Open(opts.Dialector(cfg.DSN), opts.Config)
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %w", err)
+	}
+	d := &DB{DB: gdb, pingTimeout: opts.PingTimeout}
+
+	if len(cfg.Replicas) > 0 {
+		replicas := make([]gorm.Dialector, 0, len(cfg.Replicas))
+		for _, dsn := range cfg.Replicas {
+			replicas = append(replicas, opts.Dialector(dsn))
+		}
+		d.resolver = dbresolver.Register(dbresolver.Config{
+			Replicas:          replicas,
+			Policy:            opts.Policy,
+			TraceResolverMode: true,
+		})
+		if err := gdb.Use(d.resolver); err != nil {
+			d.resolver = nil
+			_ = d.Close()
+			return nil, fmt.Errorf("注册读写分离失败: %w", err)
+		}
+	}
+
+	plugins := append([]gorm.Plugin{hdobserve.New(hdobserve.ConfigFromMySQL(cfg))}, opts.Plugins...)
+	for _, p := range plugins {
+		if err := gdb.Use(p); err != nil {
+			_ = d.Close()
+			return nil, fmt.Errorf("注册插件 %s 失败: %w", p.Name(), err)
+		}
+	}
+
+	d.ApplyPool(cfg)
+	if err := d.Ping(ctx); err != nil {
+		_ = d.Close()
+		return nil, err
+	}
+
+	if err := opts.Lifecycle.Register(opts.Name, lifecycle.Hook{
+		OnStop: func(ctx context.Context) error { return d.Close() },
+	}, lifecycle.Started()); err != nil {
+		_ = d.Close()
+		return nil, fmt.Errorf("注册数据库关闭钩子失败: %w", err)
+	}
+	return d, nil
+}
+
+// eachPool 对主库和所有副本的连接池执行 fn
+func (d *DB) eachPool(fn func(pool gorm.ConnPool) error) error {
+	if d.resolver != nil {
+		return d.resolver.Call(fn)
+	}
+	return fn(d.DB.ConnPool)
+}
+
+// Ping 检查主库和所有副本的连接
+func (d *DB) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, d.pingTimeout)
+	defer cancel()
+	return d.eachPool(func(pool gorm.ConnPool) error {
+		pinger, ok := pool.(interface {
+			PingContext(ctx context.Context) error
+		})
+		if !ok {
+			return nil
+		}
+		if err := pinger.PingContext(ctx); err != nil {
+			return fmt.Errorf("数据库健康检查失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// ApplyPool 按配置设置主库和所有副本的连接池，未设置的项使用默认值
+func (d *DB) ApplyPool(cfg hdmodel.MySQL) {
+	maxOpen := cfg.MaxOpenConns
+	if maxOpen <= 0 {
+		maxOpen = DefaultMaxOpenConns
+	}
+	maxIdle := cfg.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = DefaultMaxIdleConns
+	}
+	lifetime := time.Duration(cfg.ConnMaxLifetimeSec) * time.Second
+	if lifetime <= 0 {
+		lifetime = DefaultConnMaxLifetime
+	}
+	idleTime := time.Duration(cfg.ConnMaxIdleTimeSec) * time.Second
+	if idleTime <= 0 {
+		idleTime = DefaultConnMaxIdleTime
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_ = d.eachPool(func(pool gorm.ConnPool) error {
+		sqlDB, ok := pool.(interface {
+			SetMaxOpenConns(n int)
+			SetMaxIdleConns(n int)
+			SetConnMaxLifetime(d time.Duration)
+			SetConnMaxIdleTime(d time.Duration)
+		})
+		if !ok {
+			return nil
+		}
+		sqlDB.SetMaxOpenConns(maxOpen)
+		sqlDB.SetMaxIdleConns(maxIdle)
+		sqlDB.SetConnMaxLifetime(lifetime)
+		sqlDB.SetConnMaxIdleTime(idleTime)
+		return nil
+	})
+	d.cfg = cfg
+}
+
+// Watch 监听通用配置，变更时重新设置连接池；DSN 和副本变更需要重启服务才能生效
+func (d *DB) Watch(f *kvconfig.ConfigFactory, group string) error {
+	return f.ListenConfig(commonDataId, group, func(content string) {
+		conf := new(kvconfig.CommonConfig)
+		if err := yaml.Unmarshal([]byte(content), conf); err != nil {
+			klog.Errorf("通用配置变更无效，继续使用当前连接池配置: %v", err)
+			return
+		}
+
+		d.mu.Lock()
+		current := d.cfg
+		d.mu.Unlock()
+		if conf.MySQL.DSN != current.DSN || !slices.Equal(conf.MySQL.Replicas, current.Replicas) {
+			klog.Warnf("数据库 DSN 或副本配置已变更，需要重启服务才能生效")
+		}
+		conf.MySQL.DSN, conf.MySQL.Replicas = current.DSN, current.Replicas
+		d.ApplyPool(conf.MySQL)
+		klog.Infof("数据库连接池配置已更新: max_open=%d max_idle=%d",
+			conf.MySQL.MaxOpenConns, conf.MySQL.MaxIdleConns)
+	})
+}
+
+// Close 关闭主库和所有副本的连接
+func (d *DB) Close() error {
+	var errs []error
+	_ = d.eachPool(func(pool gorm.ConnPool) error {
+		if closer, ok := pool.(interface{ Close() error }); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return nil
+	})
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("关闭数据库失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package hdmigration
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultLockLease 默认租约时长，持有者崩溃后超过租约的锁可被其他副本接管
+	DefaultLockLease = 30 * time.Second
+	// DefaultLockTimeout 默认等待锁的超时时间
+	DefaultLockTimeout = 5 * time.Minute
+	// DefaultLockPollInterval 表锁的默认轮询间隔
+	DefaultLockPollInterval = time.Second
+)
+
+// lockPrefix MySQL GET_LOCK 锁名前缀
+const lockPrefix = "hdmigration:"
+
+// ErrLockTimeout 等待迁移锁超时
+var ErrLockTimeout = errors.New("timed out waiting for migration lock")
+
+// LockOptions 迁移锁配置
+type LockOptions struct {
+	// Lease 租约时长，默认 DefaultLockLease
+	Lease time.Duration
+	// Heartbeat 续约间隔，默认为 Lease 的三分之一
+	Heartbeat time.Duration
+	// Timeout 等待锁的超时时间，默认 DefaultLockTimeout
+	Timeout time.Duration
+	// PollInterval 表锁的轮询间隔，默认 DefaultLockPollInterval
+	PollInterval time.Duration
+	// Owner 持有者标识，默认为主机名和进程号
+	Owner string
+}
+
+// withDefaults 返回填充默认值后的配置
+func (o LockOptions) withDefaults() LockOptions {
+	if o.Lease <= 0 {
+		o.Lease = DefaultLockLease
+	}
+	if o.Heartbeat <= 0 || o.Heartbeat >= o.Lease {
+		o.Heartbeat = o.Lease / 3
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultLockTimeout
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = DefaultLockPollInterval
+	}
+	if o.Owner == "" {
+		host, _ := os.Hostname()
+		o.Owner = fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().UnixNano())
+	}
+	return o
+}
+
+// SchemaMigrationLock 通用迁移锁表，每个连接名一行
+type SchemaMigrationLock struct {
+	Name      string    `gorm:"column:name;type:varchar(64);primaryKey;comment:连接名称"`
+	Owner     string    `gorm:"column:owner;type:varchar(128);not null;comment:持有者"`
+	ExpiresAt time.Time `gorm:"column:expires_at;type:datetime;not null;comment:租约到期时间"`
+}
+
+func (SchemaMigrationLock) TableName() string {
+	return "schema_migration_locks"
+}
+
+// Lock 已持有的迁移锁
+type Lock struct {
+	name    string
+	owner   string
+	release func(ctx context.Context) error
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// AcquireLock 获取迁移锁，其他副本持有时等待直到 opts.Timeout
+// MySQL 使用 GET_LOCK，锁与专用连接绑定，持有者崩溃时随连接释放；其他数据库使用带租约的锁表，
+// 持有者定期续约，租约过期后可被接管，此时各副本的时钟偏差需远小于租约时长
+func AcquireLock(ctx context.Context, db *gorm.DB, name string, opts LockOptions) (*Lock, error) {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	var (
+		lock *Lock
+		err  error
+	)
+	if db.Dialector.Name() == "mysql" {
+		lock, err = acquireMySQLLock(ctx, db, name, opts)
+	} else {
+		lock, err = acquireTableLock(ctx, db, name, opts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("acquire migration lock %s: %w", name, err)
+	}
+	return lock, nil
+}
+
+// RunLocked 持有迁移锁执行 fn，适合将 CheckVersion、AutoMigrate 和 RecordVersion 放在一起执行，
+// 后获得锁的副本会看到已更新的版本号而跳过迁移
+func RunLocked(ctx context.Context, db *gorm.DB, name string, opts LockOptions, fn func() error) error {
+	lock, err := AcquireLock(ctx, db, name, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := lock.Release(context.Background()); err != nil {
+			klog.Errorf("释放迁移锁 %s 失败: %v", name, err)
+		}
+	}()
+	return fn()
+}
+
+// Release 停止续约并释放锁，可重复调用
+func (l *Lock) Release(ctx context.Context) error {
+	var err error
+	l.once.Do(func() {
+		close(l.stop)
+		<-l.done
+		err = l.release(ctx)
+	})
+	return err
+}
+
+// heartbeat 按间隔执行续约，直到 Release
+func (l *Lock) heartbeat(interval time.Duration, renew func() error) {
+	defer close(l.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			if err := renew(); err != nil {
+				klog.Errorf("迁移锁 %s 续约失败: owner=%s: %v", l.name, l.owner, err)
+			}
+		}
+	}
+}
+
+// newLock 创建锁并启动续约
+func newLock(name, owner string, interval time.Duration, renew func() error, release func(ctx context.Context) error) *Lock {
+	l := &Lock{
+		name:    name,
+		owner:   owner,
+		release: release,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go l.heartbeat(interval, renew)
+	return l
+}
+
+// mysqlLockName 返回 GET_LOCK 的锁名，超过 64 字符时取哈希
+func mysqlLockName(name string) string {
+	lockName := lockPrefix + name
+	if len(lockName) > 64 {
+		sum := sha256.Sum256([]byte(name))
+		lockName = lockPrefix + hex.EncodeToString(sum[:])[:32]
+	}
+	return lockName
+}
+
+// acquireMySQLLock 在专用连接上执行 GET_LOCK，续约即保持连接存活
+func acquireMySQLLock(ctx context.Context, db *gorm.DB, name string, opts LockOptions) (*Lock, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lockName := mysqlLockName(name)
+	wait := int64(time.Until(deadline(ctx, opts.Timeout)).Seconds())
+	if wait < 1 {
+		wait = 1
+	}
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, wait).Scan(&got); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if !got.Valid || got.Int64 != 1 {
+		_ = conn.Close()
+		return nil, ErrLockTimeout
+	}
+
+	renew := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), opts.Heartbeat)
+		defer cancel()
+		return conn.PingContext(ctx)
+	}
+	release := func(ctx context.Context) error {
+		defer conn.Close()
+		var released sql.NullInt64
+		return conn.QueryRowContext(ctx, "SELECT RELEASE_LOCK(?)", lockName).Scan(&released)
+	}
+	return newLock(name, opts.Owner, opts.Heartbeat, renew, release), nil
+}
+
+// acquireTableLock 通过锁表获取锁：不存在时插入，已过期或已由自己持有时接管，否则轮询等待
+func acquireTableLock(ctx context.Context, db *gorm.DB, name string, opts LockOptions) (*Lock, error) {
+	db = db.WithContext(ctx)
+	if err := db.AutoMigrate(&SchemaMigrationLock{}); err != nil {
+		return nil, fmt.Errorf("migrate schema_migration_locks table: %w", err)
+	}
+
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		ok, err := tryTableLock(db, name, opts)
+		if err != nil {
+			// 等待超时可能发生在执行 SQL 期间
+			if ctx.Err() != nil {
+				return nil, waitErr(ctx)
+			}
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, waitErr(ctx)
+		case <-ticker.C:
+		}
+	}
+
+	base := db.WithContext(context.Background())
+	renew := func() error {
+		res := base.Model(&SchemaMigrationLock{}).
+			Where("name = ? AND owner = ?", name, opts.Owner).
+			Update("expires_at", time.Now().Add(opts.Lease))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("lock lost")
+		}
+		return nil
+	}
+	release := func(ctx context.Context) error {
+		return base.WithContext(ctx).
+			Where("name = ? AND owner = ?", name, opts.Owner).
+			Delete(&SchemaMigrationLock{}).Error
+	}
+	return newLock(name, opts.Owner, opts.Heartbeat, renew, release), nil
+}
+
+// tryTableLock 尝试获取一次表锁
+func tryTableLock(db *gorm.DB, name string, opts LockOptions) (bool, error) {
+	now := time.Now()
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&SchemaMigrationLock{
+		Name:      name,
+		Owner:     opts.Owner,
+		ExpiresAt: now.Add(opts.Lease),
+	})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return true, nil
+	}
+
+	res = db.Model(&SchemaMigrationLock{}).
+		Where("name = ? AND (expires_at < ? OR owner = ?)", name, now, opts.Owner).
+		Updates(map[string]interface{}{"owner": opts.Owner, "expires_at": now.Add(opts.Lease)})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		klog.Warnf("接管迁移锁 %s: owner=%s", name, opts.Owner)
+		return true, nil
+	}
+	return false, nil
+}
+
+// waitErr 返回等待锁期间 ctx 结束的错误，超时时返回 ErrLockTimeout
+func waitErr(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrLockTimeout
+	}
+	return ctx.Err()
+}
+
+// deadline 返回 ctx 的截止时间，没有时按 timeout 计算
+func deadline(ctx context.Context, timeout time.Duration) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(timeout)
+}
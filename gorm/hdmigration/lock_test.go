@@ -0,0 +1,141 @@
+package hdmigration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lockOwner 返回锁表中的持有者，没有记录时返回空字符串
+func lockOwner(t *testing.T, db *gorm.DB, name string) string {
+	t.Helper()
+	var locks []SchemaMigrationLock
+	if err := db.Where("name = ?", name).Find(&locks).Error; err != nil {
+		t.Fatalf("查询锁表失败: %v", err)
+	}
+	if len(locks) == 0 {
+		return ""
+	}
+	return locks[0].Owner
+}
+
+func TestAcquireLock_Exclusive(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+
+	a, err := AcquireLock(ctx, db, "test", LockOptions{Owner: "a"})
+	if err != nil {
+		t.Fatalf("a 获取锁失败: %v", err)
+	}
+	if owner := lockOwner(t, db, "test"); owner != "a" {
+		t.Fatalf("锁应由 a 持有，实际 %q", owner)
+	}
+
+	wait := LockOptions{Owner: "b", Timeout: 100 * time.Millisecond, PollInterval: 10 * time.Millisecond}
+	if _, err := AcquireLock(ctx, db, "test", wait); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("锁被其他副本持有时应返回 ErrLockTimeout，实际 %v", err)
+	}
+	// 不同连接名的锁互不影响
+	other, err := AcquireLock(ctx, db, "other", wait)
+	if err != nil {
+		t.Fatalf("获取其他连接名的锁失败: %v", err)
+	}
+	_ = other.Release(ctx)
+
+	if err := a.Release(ctx); err != nil {
+		t.Fatalf("释放锁失败: %v", err)
+	}
+	if err := a.Release(ctx); err != nil {
+		t.Fatalf("重复释放不应返回错误: %v", err)
+	}
+	if owner := lockOwner(t, db, "test"); owner != "" {
+		t.Fatalf("释放后锁表应没有记录，实际 %q", owner)
+	}
+
+	b, err := AcquireLock(ctx, db, "test", wait)
+	if err != nil {
+		t.Fatalf("a 释放后 b 应能获取锁: %v", err)
+	}
+	_ = b.Release(ctx)
+}
+
+func TestAcquireLock_Takeover(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	if err := db.AutoMigrate(&SchemaMigrationLock{}); err != nil {
+		t.Fatalf("创建锁表失败: %v", err)
+	}
+
+	// 模拟持有者崩溃后留下的过期租约
+	expired := SchemaMigrationLock{Name: "test", Owner: "crashed", ExpiresAt: time.Now().Add(-time.Second)}
+	if err := db.Create(&expired).Error; err != nil {
+		t.Fatalf("写入过期租约失败: %v", err)
+	}
+	b, err := AcquireLock(ctx, db, "test", LockOptions{Owner: "b", Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("租约过期后应能接管锁: %v", err)
+	}
+	if owner := lockOwner(t, db, "test"); owner != "b" {
+		t.Fatalf("锁应由 b 接管，实际 %q", owner)
+	}
+
+	// 已由自己持有时可以重新获取
+	again, err := AcquireLock(ctx, db, "test", LockOptions{Owner: "b", Timeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("持有者重新获取锁失败: %v", err)
+	}
+	_ = again.Release(ctx)
+	_ = b.Release(ctx)
+}
+
+func TestAcquireLock_Heartbeat(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+
+	a, err := AcquireLock(ctx, db, "test", LockOptions{Owner: "a", Lease: 200 * time.Millisecond, Heartbeat: 40 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("a 获取锁失败: %v", err)
+	}
+	defer a.Release(ctx)
+
+	// 超过初始租约后，续约中的锁不能被接管
+	time.Sleep(400 * time.Millisecond)
+	wait := LockOptions{Owner: "b", Timeout: 100 * time.Millisecond, PollInterval: 10 * time.Millisecond}
+	if _, err := AcquireLock(ctx, db, "test", wait); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("持有者续约期间不应被接管，实际 %v", err)
+	}
+}
+
+func TestRunLocked(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+
+	want := errors.New("fn failed")
+	err := RunLocked(ctx, db, "test", LockOptions{Owner: "a"}, func() error {
+		if owner := lockOwner(t, db, "test"); owner != "a" {
+			t.Fatalf("执行期间应持有锁，实际 %q", owner)
+		}
+		return want
+	})
+	if !errors.Is(err, want) {
+		t.Fatalf("应返回 fn 的错误，实际 %v", err)
+	}
+	if owner := lockOwner(t, db, "test"); owner != "" {
+		t.Fatalf("fn 返回后应释放锁，实际 %q", owner)
+	}
+
+	// Runner 默认持有迁移锁执行
+	r := NewRunner(db, "test")
+	if err := r.Register(Step{Version: 1, Name: "a", UpSQL: "CREATE TABLE a (id INTEGER);"}); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	if err := r.Up(ctx, 0); err != nil {
+		t.Fatalf("升级失败: %v", err)
+	}
+	if owner := lockOwner(t, db, "test"); owner != "" {
+		t.Fatalf("升级完成后应释放锁，实际 %q", owner)
+	}
+}
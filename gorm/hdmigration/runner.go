@@ -0,0 +1,443 @@
+package hdmigration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grayscalecloud/kitexcommon/ctxx"
+	"gorm.io/gorm"
+)
+
+// ErrDrift 已执行步骤的校验和发生变化
+var ErrDrift = errors.New("applied migration changed since it was run")
+
+// StepFunc Go 迁移函数，在事务中执行
+type StepFunc func(tx *gorm.DB) error
+
+// Step 一个迁移步骤，Up/UpSQL 二选一，Down/DownSQL 可选
+type Step struct {
+	// Version 版本号，按升序执行，同一个 Runner 内不能重复
+	Version int64
+	// Name 步骤名称，如 create_orders
+	Name string
+	// Up Go 升级函数
+	Up StepFunc
+	// Down Go 回滚函数
+	Down StepFunc
+	// UpSQL 升级 SQL，多条语句以行尾分号分隔
+	UpSQL string
+	// DownSQL 回滚 SQL
+	DownSQL string
+	// Checksum Go 步骤的校验和，修改函数行为时应同步修改；SQL 步骤按 UpSQL 自动计算
+	Checksum string
+}
+
+// checksum 返回步骤的校验和
+func (s Step) checksum() string {
+	src := s.Checksum
+	if s.UpSQL != "" {
+		src = s.UpSQL
+	}
+	if src == "" {
+		src = fmt.Sprintf("%d_%s", s.Version, s.Name)
+	}
+	sum := sha256.Sum256([]byte(src))
+	return hex.EncodeToString(sum[:])
+}
+
+// SchemaMigrationStep 已执行的迁移步骤，每个连接每个版本一条记录
+type SchemaMigrationStep struct {
+	ID         uint      `gorm:"primarykey"`
+	Name       string    `gorm:"column:name;type:varchar(64);not null;uniqueIndex:idx_name_version;comment:连接名称"`
+	Version    int64     `gorm:"column:version;not null;uniqueIndex:idx_name_version;comment:步骤版本号"`
+	StepName   string    `gorm:"column:step_name;type:varchar(255);not null;comment:步骤名称"`
+	Checksum   string    `gorm:"column:checksum;type:varchar(64);not null;comment:校验和"`
+	DurationMs int64     `gorm:"column:duration_ms;not null;comment:执行耗时（毫秒）"`
+	AppliedBy  string    `gorm:"column:applied_by;type:varchar(128);comment:执行者"`
+	AppliedAt  time.Time `gorm:"column:applied_at;type:datetime;not null;comment:执行时间"`
+}
+
+func (SchemaMigrationStep) TableName() string {
+	return "schema_migration_steps"
+}
+
+// StepStatus 迁移步骤的状态
+type StepStatus struct {
+	// Version 版本号
+	Version int64
+	// Name 步骤名称
+	Name string
+	// Applied 是否已执行
+	Applied bool
+	// AppliedAt 执行时间
+	AppliedAt time.Time
+	// AppliedBy 执行者
+	AppliedBy string
+	// Drift 已执行步骤的校验和与当前定义不一致
+	Drift bool
+	// Missing 已执行但当前没有注册该步骤
+	Missing bool
+}
+
+// Runner 版本化迁移执行器，每个步骤在独立事务中执行并记录校验和、耗时和执行者
+// 注意 MySQL 的 DDL 会隐式提交事务，包含 DDL 的步骤失败时可能只执行了一部分，步骤应尽量只包含一条 DDL
+type Runner struct {
+	db    *gorm.DB
+	name  string
+	steps []Step
+	lock  *LockOptions
+}
+
+// NewRunner 创建迁移执行器，Up 和 Down 默认持有迁移锁执行，多个副本同时启动时只有一个执行迁移
+// name: 连接名称，用于区分不同数据库连接的迁移记录
+func NewRunner(db *gorm.DB, name string) *Runner {
+	return &Runner{db: db, name: name, lock: &LockOptions{}}
+}
+
+// WithLock 设置迁移锁配置，为 nil 时不加锁
+func (r *Runner) WithLock(opts *LockOptions) *Runner {
+	r.lock = opts
+	return r
+}
+
+// locked 持有迁移锁执行 fn
+func (r *Runner) locked(ctx context.Context, fn func() error) error {
+	if r.lock == nil {
+		return fn()
+	}
+	return RunLocked(ctx, r.db, r.name, *r.lock, fn)
+}
+
+// Register 注册迁移步骤
+func (r *Runner) Register(steps ...Step) error {
+	for _, s := range steps {
+		if s.Version <= 0 {
+			return fmt.Errorf("migration %q: version must be positive", s.Name)
+		}
+		if s.Up == nil && s.UpSQL == "" {
+			return fmt.Errorf("migration %d_%s: missing up", s.Version, s.Name)
+		}
+		for _, existing := range r.steps {
+			if existing.Version == s.Version {
+				return fmt.Errorf("migration %d registered twice: %s, %s", s.Version, existing.Name, s.Name)
+			}
+		}
+		r.steps = append(r.steps, s)
+	}
+	sort.Slice(r.steps, func(i, j int) bool { return r.steps[i].Version < r.steps[j].Version })
+	return nil
+}
+
+// RegisterFS 注册目录中的 SQL 迁移文件，文件名格式为 <version>_<name>.up.sql 和 <version>_<name>.down.sql，
+// 通常配合 embed.FS 使用
+func (r *Runner) RegisterFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("read migrations dir %s: %w", dir, err)
+	}
+
+	byVersion := make(map[int64]*Step)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		file := entry.Name()
+		var up bool
+		var base string
+		switch {
+		case strings.HasSuffix(file, ".up.sql"):
+			up, base = true, strings.TrimSuffix(file, ".up.sql")
+		case strings.HasSuffix(file, ".down.sql"):
+			base = strings.TrimSuffix(file, ".down.sql")
+		default:
+			continue
+		}
+		versionStr, name, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if !ok || err != nil {
+			return fmt.Errorf("migration file %s: name must be <version>_<name>.(up|down).sql", file)
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, file))
+		if err != nil {
+			return fmt.Errorf("read migration file %s: %w", file, err)
+		}
+
+		step, ok := byVersion[version]
+		if !ok {
+			step = &Step{Version: version, Name: name}
+			byVersion[version] = step
+		} else if step.Name != name {
+			return fmt.Errorf("migration %d has conflicting names: %s, %s", version, step.Name, name)
+		}
+		if up {
+			step.UpSQL = string(data)
+		} else {
+			step.DownSQL = string(data)
+		}
+	}
+
+	steps := make([]Step, 0, len(byVersion))
+	for _, step := range byVersion {
+		steps = append(steps, *step)
+	}
+	return r.Register(steps...)
+}
+
+// ensureTable 确保步骤记录表存在
+func (r *Runner) ensureTable(ctx context.Context) error {
+	if err := r.db.WithContext(ctx).AutoMigrate(&SchemaMigrationStep{}); err != nil {
+		return fmt.Errorf("migrate schema_migration_steps table: %w", err)
+	}
+	return nil
+}
+
+// applied 返回已执行的步骤，按版本号索引
+func (r *Runner) applied(ctx context.Context) (map[int64]SchemaMigrationStep, error) {
+	if err := r.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	var records []SchemaMigrationStep
+	if err := r.db.WithContext(ctx).Where("name = ?", r.name).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("load applied migrations: %w", err)
+	}
+	result := make(map[int64]SchemaMigrationStep, len(records))
+	for _, record := range records {
+		result[record.Version] = record
+	}
+	return result, nil
+}
+
+// Status 返回所有已注册和已执行步骤的状态，按版本号升序
+func (r *Runner) Status(ctx context.Context) ([]StepStatus, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]StepStatus, 0, len(r.steps))
+	for _, step := range r.steps {
+		status := StepStatus{Version: step.Version, Name: step.Name}
+		if record, ok := applied[step.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+			status.AppliedBy = record.AppliedBy
+			status.Drift = record.Checksum != step.checksum()
+			delete(applied, step.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		statuses = append(statuses, StepStatus{
+			Version:   record.Version,
+			Name:      record.StepName,
+			Applied:   true,
+			AppliedAt: record.AppliedAt,
+			AppliedBy: record.AppliedBy,
+			Missing:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// checkDrift 存在校验和变化的已执行步骤时返回 ErrDrift
+func (r *Runner) checkDrift(statuses []StepStatus) error {
+	for _, s := range statuses {
+		if s.Drift {
+			return fmt.Errorf("migration %d_%s: %w", s.Version, s.Name, ErrDrift)
+		}
+	}
+	return nil
+}
+
+// Up 按顺序执行所有版本号不大于 to 的未执行步骤，to <= 0 时执行到最新版本
+func (r *Runner) Up(ctx context.Context, to int64) error {
+	return r.locked(ctx, func() error { return r.up(ctx, to) })
+}
+
+// up 执行升级，调用方负责加锁
+func (r *Runner) up(ctx context.Context, to int64) error {
+	statuses, err := r.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.checkDrift(statuses); err != nil {
+		return err
+	}
+	applied := make(map[int64]bool, len(statuses))
+	for _, s := range statuses {
+		applied[s.Version] = s.Applied
+	}
+
+	for _, step := range r.steps {
+		if to > 0 && step.Version > to {
+			break
+		}
+		if applied[step.Version] {
+			continue
+		}
+		if err := r.apply(ctx, step); err != nil {
+			return err
+		}
+	}
+	return r.syncVersion(ctx)
+}
+
+// Down 按倒序回滚所有版本号大于 to 的已执行步骤，to 为 0 时全部回滚
+func (r *Runner) Down(ctx context.Context, to int64) error {
+	if to < 0 {
+		return fmt.Errorf("invalid target version %d", to)
+	}
+	return r.locked(ctx, func() error { return r.down(ctx, to) })
+}
+
+// down 执行回滚，调用方负责加锁
+func (r *Runner) down(ctx context.Context, to int64) error {
+	statuses, err := r.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.checkDrift(statuses); err != nil {
+		return err
+	}
+	steps := make(map[int64]Step, len(r.steps))
+	for _, step := range r.steps {
+		steps[step.Version] = step
+	}
+
+	for i := len(statuses) - 1; i >= 0; i-- {
+		s := statuses[i]
+		if s.Version <= to || !s.Applied {
+			continue
+		}
+		step, ok := steps[s.Version]
+		if !ok {
+			return fmt.Errorf("migration %d_%s: applied but not registered, cannot roll back", s.Version, s.Name)
+		}
+		if err := r.revert(ctx, step); err != nil {
+			return err
+		}
+	}
+	return r.syncVersion(ctx)
+}
+
+// apply 在事务中执行升级并记录
+func (r *Runner) apply(ctx context.Context, step Step) error {
+	start := time.Now()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := run(tx, step.Up, step.UpSQL); err != nil {
+			return err
+		}
+		return tx.Create(&SchemaMigrationStep{
+			Name:       r.name,
+			Version:    step.Version,
+			StepName:   step.Name,
+			Checksum:   step.checksum(),
+			DurationMs: time.Since(start).Milliseconds(),
+			AppliedBy:  actor(ctx),
+			AppliedAt:  start,
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("apply migration %d_%s: %w", step.Version, step.Name, err)
+	}
+	return nil
+}
+
+// revert 在事务中执行回滚并删除记录
+func (r *Runner) revert(ctx context.Context, step Step) error {
+	if step.Down == nil && step.DownSQL == "" {
+		return fmt.Errorf("migration %d_%s: missing down", step.Version, step.Name)
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := run(tx, step.Down, step.DownSQL); err != nil {
+			return err
+		}
+		return tx.Where("name = ? AND version = ?", r.name, step.Version).Delete(&SchemaMigrationStep{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("revert migration %d_%s: %w", step.Version, step.Name, err)
+	}
+	return nil
+}
+
+// syncVersion 将最新的已执行版本同步到 schema_migrations，兼容使用 CheckVersion 的代码
+func (r *Runner) syncVersion(ctx context.Context) error {
+	var latest SchemaMigrationStep
+	err := r.db.WithContext(ctx).Where("name = ?", r.name).Order("version DESC").Limit(1).Find(&latest).Error
+	if err != nil {
+		return fmt.Errorf("load latest migration: %w", err)
+	}
+	db := r.db.WithContext(ctx)
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return fmt.Errorf("migrate schema_migrations table: %w", err)
+	}
+	return RecordVersion(db, r.name, int(latest.Version), latest.StepName)
+}
+
+// run 执行 Go 函数或 SQL
+func run(tx *gorm.DB, fn StepFunc, sql string) error {
+	if fn != nil {
+		return fn(tx)
+	}
+	for _, stmt := range splitSQL(sql) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitSQL 按行尾分号拆分 SQL 语句，忽略空语句和只有注释的语句
+func splitSQL(sql string) []string {
+	var stmts []string
+	var buf strings.Builder
+	flush := func() {
+		stmt := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if stmt != "" && !commentOnly(stmt) {
+			stmts = append(stmts, stmt)
+		}
+	}
+	for _, line := range strings.Split(sql, "\n") {
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			flush()
+		}
+	}
+	flush()
+	return stmts
+}
+
+// commentOnly 判断语句是否只包含 -- 注释
+func commentOnly(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
+
+// actor 返回执行者：上下文中的用户 ID，否则为主机名
+func actor(ctx context.Context) string {
+	if userID := ctxx.GetUserID(ctx); userID != "" {
+		return userID
+	}
+	if host, err := os.Hostname(); err == nil {
+		return host
+	}
+	return "unknown"
+}
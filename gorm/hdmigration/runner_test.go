@@ -0,0 +1,246 @@
+package hdmigration
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openDB 打开内存数据库，只使用一个连接，保证所有操作看到同一个数据库
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取连接池失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	return db
+}
+
+func versions(steps []Step) []int64 {
+	var result []int64
+	for _, s := range steps {
+		result = append(result, s.Version)
+	}
+	return result
+}
+
+func TestRunner_Register(t *testing.T) {
+	noop := func(tx *gorm.DB) error { return nil }
+	r := NewRunner(nil, "test")
+	if err := r.Register(Step{Version: 2, Name: "b", Up: noop}, Step{Version: 1, Name: "a", UpSQL: "SELECT 1;"}); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	if got := versions(r.steps); !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Fatalf("步骤应按版本号排序，实际 %v", got)
+	}
+
+	tests := []struct {
+		name string
+		step Step
+		want string
+	}{
+		{"版本号为 0", Step{Name: "zero", Up: noop}, "version must be positive"},
+		{"没有 Up", Step{Version: 3, Name: "empty", DownSQL: "SELECT 1;"}, "missing up"},
+		{"版本号重复", Step{Version: 2, Name: "dup", Up: noop}, "registered twice"},
+	}
+	for _, tt := range tests {
+		if err := r.Register(tt.step); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: 应返回包含 %q 的错误，实际 %v", tt.name, tt.want, err)
+		}
+	}
+	if len(r.steps) != 2 {
+		t.Fatalf("注册失败的步骤不应加入，实际 %v", versions(r.steps))
+	}
+}
+
+func TestRunner_RegisterFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/002_add_amount.up.sql":      {Data: []byte("ALTER TABLE orders ADD COLUMN amount INTEGER;")},
+		"migrations/001_create_orders.up.sql":   {Data: []byte("CREATE TABLE orders (id INTEGER);")},
+		"migrations/001_create_orders.down.sql": {Data: []byte("DROP TABLE orders;")},
+		"migrations/README.md":                  {Data: []byte("忽略非 SQL 文件")},
+		"migrations/old/003_x.up.sql":           {Data: []byte("忽略子目录")},
+	}
+	r := NewRunner(nil, "test")
+	if err := r.RegisterFS(fsys, "migrations"); err != nil {
+		t.Fatalf("注册迁移文件失败: %v", err)
+	}
+	want := []Step{
+		{Version: 1, Name: "create_orders", UpSQL: "CREATE TABLE orders (id INTEGER);", DownSQL: "DROP TABLE orders;"},
+		{Version: 2, Name: "add_amount", UpSQL: "ALTER TABLE orders ADD COLUMN amount INTEGER;"},
+	}
+	if !reflect.DeepEqual(r.steps, want) {
+		t.Fatalf("解析结果错误\n期望: %+v\n实际: %+v", want, r.steps)
+	}
+
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{"版本号不是数字", fstest.MapFS{"m/v1_init.up.sql": {}}, "name must be"},
+		{"缺少名称", fstest.MapFS{"m/1.up.sql": {}}, "name must be"},
+		{"名称不一致", fstest.MapFS{"m/1_a.up.sql": {}, "m/1_b.down.sql": {}}, "conflicting names"},
+		{"只有 down", fstest.MapFS{"m/1_a.down.sql": {}}, "missing up"},
+		{"目录不存在", fstest.MapFS{}, "read migrations dir"},
+	}
+	for _, tt := range tests {
+		if err := NewRunner(nil, "test").RegisterFS(tt.fsys, "m"); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: 应返回包含 %q 的错误，实际 %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestRunner_UpDown(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+
+	var calls []string
+	step := func(version int64, name string) Step {
+		return Step{
+			Version: version,
+			Name:    name,
+			Up:      func(tx *gorm.DB) error { calls = append(calls, "up_"+name); return nil },
+			Down:    func(tx *gorm.DB) error { calls = append(calls, "down_"+name); return nil },
+		}
+	}
+	r := NewRunner(db, "test").WithLock(nil)
+	err := r.Register(
+		step(3, "c"),
+		Step{
+			Version: 1,
+			Name:    "create_items",
+			UpSQL:   "-- 建表\nCREATE TABLE items (id INTEGER);\nINSERT INTO items (id) VALUES (1);\n",
+			DownSQL: "DROP TABLE items;",
+		},
+		step(2, "b"),
+	)
+	if err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+
+	if err := r.Up(ctx, 2); err != nil {
+		t.Fatalf("升级到版本 2 失败: %v", err)
+	}
+	var count int64
+	if err := db.Table("items").Count(&count).Error; err != nil || count != 1 {
+		t.Fatalf("SQL 步骤应执行全部语句，实际 %d err=%v", count, err)
+	}
+	if err := r.Up(ctx, 0); err != nil {
+		t.Fatalf("升级到最新版本失败: %v", err)
+	}
+	if want := []string{"up_b", "up_c"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("应按版本号升序执行且不重复执行\n期望: %v\n实际: %v", want, calls)
+	}
+	if needs, version, err := CheckVersion(db, "test", 3); err != nil || needs || version != 3 {
+		t.Fatalf("schema_migrations 应同步为版本 3，实际 %v %d %v", needs, version, err)
+	}
+
+	statuses, err := r.Status(ctx)
+	if err != nil {
+		t.Fatalf("查询状态失败: %v", err)
+	}
+	for _, s := range statuses {
+		if !s.Applied || s.Drift || s.Missing || s.AppliedBy == "" {
+			t.Fatalf("步骤状态错误: %+v", s)
+		}
+	}
+
+	calls = nil
+	if err := r.Down(ctx, 1); err != nil {
+		t.Fatalf("回滚到版本 1 失败: %v", err)
+	}
+	if want := []string{"down_c", "down_b"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("应按版本号倒序回滚\n期望: %v\n实际: %v", want, calls)
+	}
+	if _, version, _ := CheckVersion(db, "test", 3); version != 1 {
+		t.Fatalf("回滚后 schema_migrations 应为版本 1，实际 %d", version)
+	}
+	if err := r.Down(ctx, 0); err != nil {
+		t.Fatalf("全部回滚失败: %v", err)
+	}
+	if db.Migrator().HasTable("items") {
+		t.Fatalf("回滚后 items 表应被删除")
+	}
+	if err := r.Down(ctx, -1); err == nil {
+		t.Fatalf("目标版本为负数时应返回错误")
+	}
+}
+
+func TestRunner_Drift(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+
+	r := NewRunner(db, "test").WithLock(nil)
+	if err := r.Register(
+		Step{Version: 1, Name: "a", UpSQL: "CREATE TABLE a (id INTEGER);", DownSQL: "DROP TABLE a;"},
+		Step{Version: 2, Name: "b", UpSQL: "CREATE TABLE b (id INTEGER);", DownSQL: "DROP TABLE b;"},
+	); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	if err := r.Up(ctx, 0); err != nil {
+		t.Fatalf("升级失败: %v", err)
+	}
+
+	// 修改已执行步骤的 SQL，并去掉版本 2
+	changed := NewRunner(db, "test").WithLock(nil)
+	if err := changed.Register(Step{Version: 1, Name: "a", UpSQL: "CREATE TABLE a (id INTEGER, name TEXT);"}); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	statuses, err := changed.Status(ctx)
+	if err != nil {
+		t.Fatalf("查询状态失败: %v", err)
+	}
+	if len(statuses) != 2 || !statuses[0].Drift || !statuses[1].Missing || statuses[1].Name != "b" {
+		t.Fatalf("应检测到版本 1 的校验和变化和版本 2 缺失，实际 %+v", statuses)
+	}
+	if err := changed.Up(ctx, 0); !errors.Is(err, ErrDrift) {
+		t.Fatalf("校验和变化时升级应返回 ErrDrift，实际 %v", err)
+	}
+	if err := changed.Down(ctx, 0); !errors.Is(err, ErrDrift) {
+		t.Fatalf("校验和变化时回滚应返回 ErrDrift，实际 %v", err)
+	}
+
+	// 未注册的已执行步骤无法回滚
+	missing := NewRunner(db, "test").WithLock(nil)
+	if err := missing.Register(r.steps[0]); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	if err := missing.Down(ctx, 0); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Fatalf("回滚未注册的步骤应返回错误，实际 %v", err)
+	}
+
+	// 其他连接名的记录互不影响
+	other := NewRunner(db, "other").WithLock(nil)
+	if err := other.Register(Step{Version: 1, Name: "a", UpSQL: "CREATE TABLE c (id INTEGER);"}); err != nil {
+		t.Fatalf("注册失败: %v", err)
+	}
+	if err := other.Up(ctx, 0); err != nil {
+		t.Fatalf("其他连接名的迁移不应受影响: %v", err)
+	}
+}
+
+func TestSplitSQL(t *testing.T) {
+	sql := "-- 建表\nCREATE TABLE a (\n  id INTEGER\n);\n\nINSERT INTO a VALUES (1);  \nSELECT 1;\n-- 只有注释\n"
+	want := []string{
+		"-- 建表\nCREATE TABLE a (\n  id INTEGER\n);",
+		"INSERT INTO a VALUES (1);",
+		"SELECT 1;",
+	}
+	if got := splitSQL(sql); !reflect.DeepEqual(got, want) {
+		t.Fatalf("拆分结果错误\n期望: %q\n实际: %q", want, got)
+	}
+}
@@ -0,0 +1,216 @@
+package hdobserve
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/grayscalecloud/kitexcommon/ctxx"
+	"github.com/grayscalecloud/kitexcommon/hdmodel"
+	"github.com/grayscalecloud/kitexcommon/monitor"
+	"github.com/grayscalecloud/kitexcommon/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// PluginName 插件名称
+const PluginName = "hdobserve"
+
+// DefaultSlowThreshold 默认慢查询阈值
+const DefaultSlowThreshold = 200 * time.Millisecond
+
+// tracerName 数据库 span 使用的 tracer 名称
+const tracerName = "github.com/grayscalecloud/kitexcommon/gorm/hdobserve"
+
+// 语句执行期间保存在 gorm 实例中的键
+const (
+	spanKey  = PluginName + ":span"
+	startKey = PluginName + ":start"
+)
+
+// unknownTable 无法确定表名时的指标标签，如 Raw/Exec
+const unknownTable = "unknown"
+
+var (
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gorm_query_duration_seconds",
+		Help:    "按表和操作统计的 SQL 执行耗时",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"table", "operation"})
+	queryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gorm_query_errors_total",
+		Help: "按表和操作统计的 SQL 执行错误次数",
+	}, []string{"table", "operation"})
+
+	metricsMu  sync.Mutex
+	metricsReg *prometheus.Registry
+)
+
+// registerMetrics 将数据库指标注册到 monitor.Reg，monitor 未开启 Prometheus 时只计数不暴露
+func registerMetrics() {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	if monitor.Reg == nil || monitor.Reg == metricsReg {
+		return
+	}
+	for _, c := range []prometheus.Collector{queryDuration, queryErrors} {
+		var are prometheus.AlreadyRegisteredError
+		if err := monitor.Reg.Register(c); err != nil && !errors.As(err, &are) {
+			klog.Warnf("注册数据库指标失败: %v", err)
+			return
+		}
+	}
+	metricsReg = monitor.Reg
+}
+
+// Config 可观测性插件配置
+type Config struct {
+	// SlowThreshold 慢查询阈值，默认 DefaultSlowThreshold，为负数时不记录慢查询
+	SlowThreshold time.Duration
+}
+
+// ConfigFromMySQL 根据 hdmodel.MySQL 生成配置
+func ConfigFromMySQL(cfg hdmodel.MySQL) Config {
+	return Config{SlowThreshold: time.Duration(cfg.SlowThresholdMs) * time.Millisecond}
+}
+
+// Plugin 数据库可观测性插件，为每条语句创建 OTel 子 span，记录耗时直方图和错误计数，
+// 并将超过阈值的慢查询脱敏后通过 klog 记录
+type Plugin struct {
+	slowThreshold time.Duration
+	tracer        trace.Tracer
+	desensitizer  *utils.Desensitizer
+}
+
+// New 创建可观测性插件，通过 db.Use(hdobserve.New(cfg)) 注册
+func New(cfg Config) *Plugin {
+	if cfg.SlowThreshold == 0 {
+		cfg.SlowThreshold = DefaultSlowThreshold
+	}
+	return &Plugin{
+		slowThreshold: cfg.SlowThreshold,
+		tracer:        otel.Tracer(tracerName),
+		desensitizer:  utils.NewDesensitizer().SetKeepCount(1, 1),
+	}
+}
+
+// Name 实现 gorm.Plugin 接口
+func (p *Plugin) Name() string {
+	return PluginName
+}
+
+// Initialize 实现 gorm.Plugin 接口，在每类语句执行前后注册回调
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	registerMetrics()
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register(PluginName+":before_create", p.before("create")),
+		cb.Create().After("gorm:create").Register(PluginName+":after_create", p.after("create")),
+		cb.Query().Before("gorm:query").Register(PluginName+":before_query", p.before("query")),
+		cb.Query().After("gorm:query").Register(PluginName+":after_query", p.after("query")),
+		cb.Update().Before("gorm:update").Register(PluginName+":before_update", p.before("update")),
+		cb.Update().After("gorm:update").Register(PluginName+":after_update", p.after("update")),
+		cb.Delete().Before("gorm:delete").Register(PluginName+":before_delete", p.before("delete")),
+		cb.Delete().After("gorm:delete").Register(PluginName+":after_delete", p.after("delete")),
+		cb.Row().Before("gorm:row").Register(PluginName+":before_row", p.before("row")),
+		cb.Row().After("gorm:row").Register(PluginName+":after_row", p.after("row")),
+		cb.Raw().Before("gorm:raw").Register(PluginName+":before_raw", p.before("raw")),
+		cb.Raw().After("gorm:raw").Register(PluginName+":after_raw", p.after("raw")),
+	} {
+		if err != nil {
+			return fmt.Errorf("注册数据库可观测性回调失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// before 开始 span 并记录开始时间
+func (p *Plugin) before(op string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := p.tracer.Start(db.Statement.Context, "gorm."+op, trace.WithSpanKind(trace.SpanKindClient))
+		db.Statement.Context = ctx
+		db.InstanceSet(spanKey, span)
+		db.InstanceSet(startKey, time.Now())
+	}
+}
+
+// after 结束 span，记录指标和慢查询
+func (p *Plugin) after(op string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+		start, _ := v.(time.Time)
+		elapsed := time.Since(start)
+
+		stmt := db.Statement
+		ctx := stmt.Context
+		table := stmt.Table
+		if table == "" {
+			table = unknownTable
+		}
+		err := db.Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = nil
+		}
+
+		queryDuration.WithLabelValues(table, op).Observe(elapsed.Seconds())
+		if err != nil {
+			queryErrors.WithLabelValues(table, op).Inc()
+		}
+
+		if v, ok := db.InstanceGet(spanKey); ok {
+			if span, ok := v.(trace.Span); ok {
+				span.SetAttributes(
+					attribute.String("db.system", db.Dialector.Name()),
+					attribute.String("db.sql.table", table),
+					attribute.String("db.operation", op),
+					attribute.String("db.statement", stmt.SQL.String()),
+					attribute.Int64("db.rows_affected", db.RowsAffected),
+					attribute.String("tenant.id", ctxx.GetTenantID(ctx)),
+				)
+				if err != nil {
+					span.RecordError(err)
+					span.SetStatus(codes.Error, err.Error())
+				}
+				span.End()
+			}
+		}
+
+		if p.slowThreshold > 0 && elapsed >= p.slowThreshold {
+			klog.CtxWarnf(ctx, "慢查询: table=%s op=%s rows=%d duration=%s tenant_id=%s sql=%s",
+				table, op, db.RowsAffected, elapsed, ctxx.GetTenantID(ctx), p.redact(db))
+		}
+	}
+}
+
+// redact 返回参数脱敏后的 SQL，字符串参数只保留首尾字符
+func (p *Plugin) redact(db *gorm.DB) string {
+	stmt := db.Statement
+	vars := make([]interface{}, len(stmt.Vars))
+	for i, v := range stmt.Vars {
+		if valuer, ok := v.(driver.Valuer); ok {
+			if value, err := valuer.Value(); err == nil {
+				v = value
+			}
+		}
+		switch value := v.(type) {
+		case string:
+			vars[i] = p.desensitizer.DesensitizeCustom(value)
+		case []byte:
+			vars[i] = p.desensitizer.DesensitizeCustom(string(value))
+		default:
+			vars[i] = v
+		}
+	}
+	return db.Dialector.Explain(stmt.SQL.String(), vars...)
+}
@@ -0,0 +1,220 @@
+package hdtenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/grayscalecloud/kitexcommon/ctxx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// PluginName 插件名称
+const PluginName = "hdtenant"
+
+// TagName 标记租户隔离字段的结构体标签，例如：
+//
+//	type Order struct {
+//		ID         int64
+//		TenantID   string `hdtenant:"tenant"`
+//		MerchantID string `hdtenant:"merchant"`
+//	}
+const TagName = "hdtenant"
+
+// 标签值
+const (
+	// TagTenant 租户字段，租户隔离开启时必须有租户 ID
+	TagTenant = "tenant"
+	// TagMerchant 商户字段，商户隔离开启时必须有商户 ID
+	TagMerchant = "merchant"
+)
+
+var (
+	// ErrMissingTenant 租户隔离开启但上下文中没有租户 ID
+	ErrMissingTenant = errors.New("租户隔离已开启但上下文中没有租户 ID")
+	// ErrMissingMerchant 商户隔离开启但上下文中没有商户 ID
+	ErrMissingMerchant = errors.New("商户隔离已开启但上下文中没有商户 ID")
+	// ErrTenantMismatch 写入的记录属于其他租户或商户
+	ErrTenantMismatch = errors.New("记录的租户或商户与上下文不一致")
+)
+
+// Plugin 租户隔离插件，为带 hdtenant 标签的模型自动追加租户和商户条件，创建时自动填充对应字段
+// 上下文中缺少租户或商户 ID 时拒绝执行，只能通过本进程内的 ctxx.WithTenantIsolation(ctx, false)
+// 或 ctxx.WithMerchantIsolation(ctx, false) 关闭，每次关闭都会记录审计日志；
+// Raw/Exec 执行的 SQL 不经过这些回调，需要自行处理
+type Plugin struct{}
+
+// New 创建租户隔离插件，通过 db.Use(hdtenant.New()) 注册
+func New() *Plugin {
+	return &Plugin{}
+}
+
+// Name 实现 gorm.Plugin 接口
+func (p *Plugin) Name() string {
+	return PluginName
+}
+
+// Initialize 实现 gorm.Plugin 接口，注册查询、更新、删除和创建回调
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("gorm:create").Register(PluginName+":create", p.assign),
+		cb.Query().Before("gorm:query").Register(PluginName+":query", p.scope("query")),
+		cb.Row().Before("gorm:row").Register(PluginName+":row", p.scope("row")),
+		cb.Update().Before("gorm:update").Register(PluginName+":update", p.scope("update")),
+		cb.Delete().Before("gorm:delete").Register(PluginName+":delete", p.scope("delete")),
+	} {
+		if err != nil {
+			return fmt.Errorf("注册租户隔离回调失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// scopeFields 模型的租户和商户字段
+type scopeFields struct {
+	tenant   *schema.Field
+	merchant *schema.Field
+}
+
+// fieldsOf 返回模型中带 hdtenant 标签的字段，模型没有标签时返回 false
+func fieldsOf(sch *schema.Schema) (scopeFields, bool) {
+	var f scopeFields
+	if sch == nil {
+		return f, false
+	}
+	for _, field := range sch.Fields {
+		switch field.Tag.Get(TagName) {
+		case TagTenant:
+			f.tenant = field
+		case TagMerchant:
+			f.merchant = field
+		}
+	}
+	return f, f.tenant != nil || f.merchant != nil
+}
+
+// scopeValues 当前上下文需要应用的租户和商户 ID，为空表示不限制
+type scopeValues struct {
+	tenantID   string
+	merchantID string
+}
+
+// resolve 根据 ctxx 中的隔离开关确定需要应用的条件，隔离开启但没有租户或商户 ID 时返回错误
+func resolve(db *gorm.DB, op string, f scopeFields) (scopeValues, error) {
+	ctx := db.Statement.Context
+	var v scopeValues
+	if f.tenant != nil {
+		if !ctxx.IsTenantIsolationEnabled(ctx) {
+			audit(ctx, db.Statement.Table, op, "tenant")
+		} else if v.tenantID = ctxx.GetTenantID(ctx); v.tenantID == "" {
+			return v, ErrMissingTenant
+		}
+	}
+	if f.merchant != nil {
+		if !ctxx.IsMerchantIsolationEnabled(ctx) {
+			audit(ctx, db.Statement.Table, op, "merchant")
+		} else if v.merchantID = ctxx.GetMerchantID(ctx); v.merchantID == "" {
+			return v, ErrMissingMerchant
+		}
+	}
+	return v, nil
+}
+
+// audit 记录关闭隔离的审计日志
+func audit(ctx context.Context, table, op, scope string) {
+	klog.CtxWarnf(ctx, "[审计] 绕过%s隔离: table=%s op=%s tenant_id=%s user_id=%s request_id=%s",
+		scope, table, op, ctxx.GetTenantID(ctx), ctxx.GetUserID(ctx), ctxx.GetRequestID(ctx))
+}
+
+// scope 为查询、更新和删除追加租户和商户条件
+func (p *Plugin) scope(op string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil {
+			return
+		}
+		f, ok := fieldsOf(db.Statement.Schema)
+		if !ok {
+			return
+		}
+		v, err := resolve(db, op, f)
+		if err != nil {
+			_ = db.AddError(fmt.Errorf("%s %s: %w", op, db.Statement.Table, err))
+			return
+		}
+
+		var exprs []clause.Expression
+		if v.tenantID != "" {
+			exprs = append(exprs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.tenant.DBName}, Value: v.tenantID})
+		}
+		if v.merchantID != "" {
+			exprs = append(exprs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.merchant.DBName}, Value: v.merchantID})
+		}
+		if len(exprs) > 0 {
+			db.Statement.AddClause(clause.Where{Exprs: exprs})
+		}
+	}
+}
+
+// assign 创建记录时填充租户和商户字段，记录已有其他租户或商户时拒绝写入
+func (p *Plugin) assign(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+	f, ok := fieldsOf(db.Statement.Schema)
+	if !ok {
+		return
+	}
+	v, err := resolve(db, "create", f)
+	if err == nil {
+		err = assignField(db.Statement, f.tenant, v.tenantID)
+	}
+	if err == nil {
+		err = assignField(db.Statement, f.merchant, v.merchantID)
+	}
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("create %s: %w", db.Statement.Table, err))
+	}
+}
+
+// assignField 为待创建的每条记录设置字段值
+func assignField(stmt *gorm.Statement, field *schema.Field, value string) error {
+	if field == nil || value == "" {
+		return nil
+	}
+	ctx := stmt.Context
+	set := func(rv reflect.Value) error {
+		if current, zero := field.ValueOf(ctx, rv); !zero {
+			if fmt.Sprint(current) != value {
+				return ErrTenantMismatch
+			}
+			return nil
+		}
+		return field.Set(ctx, rv, value)
+	}
+
+	rv := stmt.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			elem := reflect.Indirect(rv.Index(i))
+			if elem.Kind() != reflect.Struct {
+				continue
+			}
+			if err := set(elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		return set(rv)
+	default:
+		// 使用 map 创建时由 SetColumn 写入
+		stmt.SetColumn(field.DBName, value, true)
+		return nil
+	}
+}
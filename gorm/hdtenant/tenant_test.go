@@ -0,0 +1,219 @@
+package hdtenant
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/grayscalecloud/kitexcommon/ctxx"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type order struct {
+	ID         int64
+	TenantID   string `hdtenant:"tenant"`
+	MerchantID string `hdtenant:"merchant"`
+	Amount     int
+}
+
+// bypass 关闭租户和商户隔离，用于准备和检查测试数据
+var bypass = ctxx.WithMerchantIsolation(ctxx.WithTenantIsolation(context.Background(), false), false)
+
+// scoped 返回带租户和商户 ID 的 context
+func scoped(tenantID, merchantID string) context.Context {
+	return ctxx.WithMerchantID(ctxx.WithTenantID(context.Background(), tenantID), merchantID)
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	if err := db.Use(New()); err != nil {
+		t.Fatalf("注册插件失败: %v", err)
+	}
+	if err := db.AutoMigrate(&order{}); err != nil {
+		t.Fatalf("创建表失败: %v", err)
+	}
+	seed := []order{
+		{TenantID: "t1", MerchantID: "m1", Amount: 1},
+		{TenantID: "t1", MerchantID: "m1", Amount: 2},
+		{TenantID: "t1", MerchantID: "m2", Amount: 3},
+		{TenantID: "t2", MerchantID: "m1", Amount: 4},
+	}
+	if err := db.WithContext(bypass).Create(&seed).Error; err != nil {
+		t.Fatalf("准备测试数据失败: %v", err)
+	}
+	return db
+}
+
+// amounts 返回所有记录的 Amount，忽略隔离条件
+func amounts(t *testing.T, db *gorm.DB) []int {
+	t.Helper()
+	var orders []order
+	if err := db.WithContext(bypass).Find(&orders).Error; err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	var result []int
+	for _, o := range orders {
+		result = append(result, o.Amount)
+	}
+	sort.Ints(result)
+	return result
+}
+
+func TestPlugin_ScopesQueryUpdateDelete(t *testing.T) {
+	db := openDB(t)
+	ctx := scoped("t1", "m1")
+
+	var orders []order
+	if err := db.WithContext(ctx).Find(&orders).Error; err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("应只查到 t1/m1 的 2 条记录，实际 %+v", orders)
+	}
+	var count int64
+	if err := db.WithContext(ctx).Model(&order{}).Count(&count).Error; err != nil || count != 2 {
+		t.Fatalf("计数应为 2，实际 %d err=%v", count, err)
+	}
+
+	res := db.WithContext(ctx).Model(&order{}).Where("amount > ?", 0).Update("amount", 10)
+	if res.Error != nil || res.RowsAffected != 2 {
+		t.Fatalf("应只更新 t1/m1 的 2 条记录，实际 %d err=%v", res.RowsAffected, res.Error)
+	}
+	if got := amounts(t, db); !equalInts(got, []int{3, 4, 10, 10}) {
+		t.Fatalf("更新后的数据错误: %v", got)
+	}
+
+	res = db.WithContext(ctx).Where("amount > ?", 0).Delete(&order{})
+	if res.Error != nil || res.RowsAffected != 2 {
+		t.Fatalf("应只删除 t1/m1 的 2 条记录，实际 %d err=%v", res.RowsAffected, res.Error)
+	}
+	if got := amounts(t, db); !equalInts(got, []int{3, 4}) {
+		t.Fatalf("删除后的数据错误: %v", got)
+	}
+
+	// 生成的 SQL 中带有租户和商户条件
+	stmt := db.Session(&gorm.Session{DryRun: true}).WithContext(ctx).Find(&[]order{}).Statement
+	sql := stmt.SQL.String()
+	if !strings.Contains(sql, "`orders`.`tenant_id` = ?") || !strings.Contains(sql, "`orders`.`merchant_id` = ?") {
+		t.Fatalf("查询缺少隔离条件: %s", sql)
+	}
+}
+
+func TestPlugin_CreateFillsColumns(t *testing.T) {
+	db := openDB(t)
+	o := order{Amount: 5}
+	if err := db.WithContext(scoped("t3", "m3")).Create(&o).Error; err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	if o.TenantID != "t3" || o.MerchantID != "m3" {
+		t.Fatalf("创建时应填充租户和商户字段，实际 %+v", o)
+	}
+
+	batch := []order{{Amount: 6}, {TenantID: "t3", Amount: 7}}
+	if err := db.WithContext(scoped("t3", "m3")).Create(&batch).Error; err != nil {
+		t.Fatalf("批量创建失败: %v", err)
+	}
+	for _, o := range batch {
+		if o.TenantID != "t3" || o.MerchantID != "m3" {
+			t.Fatalf("批量创建时应填充每条记录，实际 %+v", o)
+		}
+	}
+}
+
+func TestPlugin_CreateRejectsMismatch(t *testing.T) {
+	db := openDB(t)
+	for _, o := range []order{
+		{TenantID: "t2", Amount: 1},
+		{MerchantID: "m2", Amount: 1},
+	} {
+		err := db.WithContext(scoped("t1", "m1")).Create(&o).Error
+		if !errors.Is(err, ErrTenantMismatch) {
+			t.Fatalf("写入其他租户或商户的记录应返回 ErrTenantMismatch，实际 %v", err)
+		}
+	}
+	if got := amounts(t, db); len(got) != 4 {
+		t.Fatalf("被拒绝的记录不应写入，实际 %v", got)
+	}
+}
+
+func TestPlugin_FailsClosed(t *testing.T) {
+	db := openDB(t)
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{"没有租户 ID", ctxx.WithMerchantID(context.Background(), "m1"), ErrMissingTenant},
+		{"没有商户 ID", ctxx.WithTenantID(context.Background(), "t1"), ErrMissingMerchant},
+		// 上游通过 metainfo 传来的开关不能关闭隔离
+		{"远程关闭隔离", metainfo.WithPersistentValue(
+			metainfo.WithValue(context.Background(), ctxx.TenantIsolationKey, "false"),
+			ctxx.MerchantIsolationKey, "false"), ErrMissingTenant},
+	}
+	for _, tt := range tests {
+		var orders []order
+		if err := db.WithContext(tt.ctx).Find(&orders).Error; !errors.Is(err, tt.want) {
+			t.Errorf("%s: 查询应返回 %v，实际 %v", tt.name, tt.want, err)
+		}
+		if err := db.WithContext(tt.ctx).Where("amount > ?", 0).Delete(&order{}).Error; !errors.Is(err, tt.want) {
+			t.Errorf("%s: 删除应返回 %v，实际 %v", tt.name, tt.want, err)
+		}
+		if err := db.WithContext(tt.ctx).Create(&order{Amount: 1}).Error; !errors.Is(err, tt.want) {
+			t.Errorf("%s: 创建应返回 %v，实际 %v", tt.name, tt.want, err)
+		}
+	}
+	if got := amounts(t, db); len(got) != 4 {
+		t.Fatalf("被拒绝的操作不应修改数据，实际 %v", got)
+	}
+}
+
+func TestPlugin_BypassAudited(t *testing.T) {
+	db := openDB(t)
+	var buf bytes.Buffer
+	klog.SetOutput(&buf)
+	defer klog.SetOutput(os.Stderr)
+
+	ctx := ctxx.WithUserID(ctxx.WithTenantIsolation(scoped("t1", "m1"), false), "u1")
+	var orders []order
+	if err := db.WithContext(ctx).Find(&orders).Error; err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	// 只关闭租户隔离，商户条件仍然生效
+	if len(orders) != 3 {
+		t.Fatalf("应查到所有租户中 m1 的 3 条记录，实际 %+v", orders)
+	}
+
+	log := buf.String()
+	for _, want := range []string{"[审计] 绕过tenant隔离", "table=orders", "op=query", "user_id=u1"} {
+		if !strings.Contains(log, want) {
+			t.Fatalf("审计日志缺少 %q: %s", want, log)
+		}
+	}
+	if strings.Contains(log, "绕过merchant隔离") {
+		t.Fatalf("未关闭商户隔离时不应记录商户审计日志: %s", log)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -18,6 +18,9 @@ var (
 	Unwrap = errors.Unwrap
 )
 
+// DefaultCode WrapWithMessage 包装普通错误时使用的默认错误码
+const DefaultCode int64 = -1
+
 type EnumsType interface {
 	ToInt() int64
 }
@@ -141,7 +144,7 @@ func WrapWithMessage(err error, message string) error {
 	}
 
 	return &BusinessError{
-		Code:    NewDefaultEnumsType(-1), // 默认错误码
+		Code:    NewDefaultEnumsType(DefaultCode), // 默认错误码
 		Message: message,
 		Cause:   err,
 		Stack:   captureStack(2),
@@ -171,6 +174,31 @@ func (e *BusinessError) Unwrap() error {
 	return e.Cause
 }
 
+// Is 按错误码判断是否为同一类错误
+// 使跨 RPC 重建的错误也能通过 errors.Is 与本地定义的 BusinessError 或 errno.ErrNo 比较
+// 默认错误码和 0 不代表具体的错误类别，只有错误码和消息都相同时才视为同一类错误
+func (e *BusinessError) Is(target error) bool {
+	if e == nil || e.Code == nil {
+		return false
+	}
+	code := e.Code.ToInt()
+	switch t := target.(type) {
+	case *BusinessError:
+		if t == nil || t.Code == nil || t.Code.ToInt() != code {
+			return false
+		}
+		return !isGenericCode(code) || t.Message == e.Message
+	case interface{ GetErrCode() int64 }:
+		return !isGenericCode(code) && t.GetErrCode() == code
+	}
+	return false
+}
+
+// isGenericCode 是否为不代表具体错误类别的错误码
+func isGenericCode(code int64) bool {
+	return code == DefaultCode || code == 0
+}
+
 // GetCode 获取错误码
 func (e *BusinessError) GetCode() int64 {
 	return e.Code.ToInt()
@@ -243,6 +271,15 @@ func captureStack(skip int) []StackInfo {
 	return stack
 }
 
+// ExtraRetryable BizExtra 中标记错误可重试的键，值为 "true"
+const ExtraRetryable = "retryable"
+
+// IsRetryable 检查错误是否被标记为可重试（如服务端正在关闭、限流）
+func IsRetryable(err error) bool {
+	var be *BusinessError
+	return errors.As(err, &be) && be.GetExtra(ExtraRetryable) == "true"
+}
+
 // IsBusinessError 检查错误是否为业务错误
 func IsBusinessError(err error) bool {
 	var businessError *BusinessError
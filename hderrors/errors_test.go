@@ -0,0 +1,49 @@
+package hderrors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grayscalecloud/kitexcommon/consts/errno"
+)
+
+func TestBusinessError_IsByCode(t *testing.T) {
+	local := NewError(NewDefaultEnumsType(1000), "记录不存在")
+	// 模拟跨 RPC 重建的错误：错误码相同但不是同一个实例
+	remote := NewError(NewDefaultEnumsType(1000), "record not found").WithExtra("id", "42")
+
+	if !errors.Is(remote, local) {
+		t.Fatalf("错误码相同的 BusinessError 应通过 errors.Is 判断为同一类错误")
+	}
+	if errors.Is(remote, NewError(NewDefaultEnumsType(1010), "记录已存在")) {
+		t.Fatalf("错误码不同的 BusinessError 不应相等")
+	}
+	if !errors.Is(Wrap(remote, NewDefaultEnumsType(502), "调用失败"), local) {
+		t.Fatalf("包装后的错误应能通过 errors.Is 找到原始错误码")
+	}
+
+	limited := NewError(NewDefaultEnumsType(int64(errno.Err_TooManyRequest)), "too many requests")
+	if !errors.Is(limited, errno.TooManyRequest) {
+		t.Fatalf("BusinessError 应能与相同错误码的 errno.ErrNo 比较")
+	}
+
+	var be *BusinessError
+	if !errors.As(remote, &be) || be.GetCode() != 1000 || be.GetExtra("id") != "42" {
+		t.Fatalf("errors.As 应得到原始错误码和额外信息")
+	}
+}
+
+func TestBusinessError_IsDefaultCode(t *testing.T) {
+	a := WrapWithMessage(errors.New("dial tcp: timeout"), "查询订单失败")
+	b := WrapWithMessage(errors.New("record not found"), "查询用户失败")
+
+	if errors.Is(a, b) {
+		t.Fatalf("默认错误码的 BusinessError 不应仅凭错误码相等")
+	}
+	if !errors.Is(a, NewError(NewDefaultEnumsType(DefaultCode), "查询订单失败")) {
+		t.Fatalf("默认错误码且消息相同的 BusinessError 应相等")
+	}
+	if errors.Is(NewError(NewDefaultEnumsType(0), "a"), NewError(NewDefaultEnumsType(0), "b")) {
+		t.Fatalf("错误码为 0 的 BusinessError 不应仅凭错误码相等")
+	}
+}
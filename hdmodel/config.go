@@ -3,6 +3,7 @@ package hdmodel
 type Kitex struct {
 	Service         string `yaml:"service"`
 	Address         string `yaml:"address"`
+	Zone            string `yaml:"zone"`
 	MetricsPort     string `yaml:"metrics_port"`
 	EnablePprof     bool   `yaml:"enable_pprof"`
 	EnableGzip      bool   `yaml:"enable_gzip"`
@@ -22,13 +23,31 @@ type OTel struct {
 	Enable   bool   `yaml:"enable"`
 	Endpoint string `yaml:"endpoint"`
 }
+
+// Registry 注册中心配置，RegistryAddress 支持逗号分隔的多个地址
 type Registry struct {
-	RegistryAddress string `yaml:"registry_address"`
-	Username        string `yaml:"username"`
-	Password        string `yaml:"password"`
-	NamespaceId     string `yaml:"namespace_id"`
-	Group           string `yaml:"group"`
-	DataId          string `yaml:"data_id"`
+	RegistryAddress string      `yaml:"registry_address"`
+	Username        string      `yaml:"username"`
+	Password        string      `yaml:"password"`
+	NamespaceId     string      `yaml:"namespace_id"`
+	Group           string      `yaml:"group"`
+	DataId          string      `yaml:"data_id"`
+	ContextPath     string      `yaml:"context_path"`
+	AccessKey       string      `yaml:"access_key"`
+	SecretKey       string      `yaml:"secret_key"`
+	TLS             RegistryTLS `yaml:"tls"`
+	LogDir          string      `yaml:"log_dir"`
+	CacheDir        string      `yaml:"cache_dir"`
+}
+
+// RegistryTLS 注册中心 TLS 配置
+type RegistryTLS struct {
+	Enable     bool   `yaml:"enable"`
+	TrustAll   bool   `yaml:"trust_all"`
+	CaFile     string `yaml:"ca_file"`
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	ServerName string `yaml:"server_name"`
 }
 
 type Monitor struct {
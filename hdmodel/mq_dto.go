@@ -1,5 +1,11 @@
 package hdmodel
 
+import (
+	"context"
+
+	"github.com/grayscalecloud/kitexcommon/ctxx"
+)
+
 type UserImageMqDto struct {
 	UserID   int64
 	TenantId string
@@ -17,3 +23,19 @@ type ActivityMqDto struct {
 	ActivityId string
 	TenantId   string
 }
+
+// Message 消息队列的通用信封，Headers 携带生产者的租户、用户、请求 ID 和链路信息
+type Message[T any] struct {
+	Headers map[string]string `json:"headers,omitempty"`
+	Payload T                 `json:"payload"`
+}
+
+// NewMessage 使用 ctx 中的上下文信息创建消息
+func NewMessage[T any](ctx context.Context, payload T) Message[T] {
+	return Message[T]{Headers: ctxx.MarshalCarrier(ctx), Payload: payload}
+}
+
+// Context 在 ctx 上恢复生产者的上下文信息，消费者应使用返回的 context 处理消息
+func (m Message[T]) Context(ctx context.Context) context.Context {
+	return ctxx.FromCarrier(ctx, m.Headers)
+}
@@ -10,6 +10,18 @@ type PasetoSecretConfig struct {
 }
 type MySQL struct {
 	DSN string `yaml:"dsn"`
+	// Replicas 只读副本 DSN，配置后读请求按策略分发到副本
+	Replicas []string `yaml:"replicas"`
+	// MaxOpenConns 每个库的最大打开连接数，为 0 时使用默认值
+	MaxOpenConns int `yaml:"max_open_conns"`
+	// MaxIdleConns 每个库的最大空闲连接数，为 0 时使用默认值
+	MaxIdleConns int `yaml:"max_idle_conns"`
+	// ConnMaxLifetimeSec 连接最长存活时间（秒），为 0 时使用默认值
+	ConnMaxLifetimeSec int `yaml:"conn_max_lifetime_sec"`
+	// ConnMaxIdleTimeSec 连接最长空闲时间（秒），为 0 时使用默认值
+	ConnMaxIdleTimeSec int `yaml:"conn_max_idle_time_sec"`
+	// SlowThresholdMs 慢查询阈值（毫秒），为 0 时使用默认值
+	SlowThresholdMs int `yaml:"slow_threshold_ms"`
 }
 
 type Redis struct {
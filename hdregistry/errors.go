@@ -0,0 +1,77 @@
+// Package hdregistry 提供服务端和客户端套件共用的注册中心工具
+package hdregistry
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// 注册中心错误类型，可通过 errors.Is 判断
+var (
+	// ErrInvalidAddress 注册中心地址格式错误
+	ErrInvalidAddress = errors.New("注册中心地址无效")
+	// ErrAuthFailed 注册中心认证失败
+	ErrAuthFailed = errors.New("注册中心认证失败")
+	// ErrUnreachable 注册中心不可达
+	ErrUnreachable = errors.New("注册中心不可达")
+)
+
+// RegistryError 创建注册中心客户端时产生的错误
+type RegistryError struct {
+	// Registry 注册中心类型，如 nacos、consul
+	Registry string
+	// Addr 注册中心地址
+	Addr string
+	// Kind 错误类型，取值为 ErrInvalidAddress、ErrAuthFailed 或 ErrUnreachable
+	Kind error
+	// Err 原始错误
+	Err error
+}
+
+// Error 实现 error 接口
+func (e *RegistryError) Error() string {
+	return fmt.Sprintf("%v [%s %s]: %v", e.Kind, e.Registry, e.Addr, e.Err)
+}
+
+// Unwrap 同时暴露错误类型和原始错误
+func (e *RegistryError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// NewInvalidAddressError 创建地址无效错误
+func NewInvalidAddressError(registry, addr string, err error) *RegistryError {
+	return &RegistryError{Registry: registry, Addr: addr, Kind: ErrInvalidAddress, Err: err}
+}
+
+// NewRegistryError 根据原始错误推断错误类型并创建注册中心错误
+func NewRegistryError(registry, addr string, err error) *RegistryError {
+	return &RegistryError{Registry: registry, Addr: addr, Kind: classify(err), Err: err}
+}
+
+// IsRetryable 判断错误是否值得重试，地址错误和认证失败重试无意义
+func IsRetryable(err error) bool {
+	return !errors.Is(err, ErrInvalidAddress) && !errors.Is(err, ErrAuthFailed)
+}
+
+// classify 根据原始错误推断错误类型，无法识别的错误按不可达处理
+func classify(err error) error {
+	var addrErr *net.AddrError
+	if errors.As(err, &addrErr) {
+		return ErrInvalidAddress
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, kw := range []string{"403", "401", "unauthorized", "forbidden", "user not found", "password", "access denied"} {
+		if strings.Contains(msg, kw) {
+			return ErrAuthFailed
+		}
+	}
+	for _, kw := range []string{"missing port", "invalid port", "too many colons", "invalid uri", "parse"} {
+		if strings.Contains(msg, kw) {
+			return ErrInvalidAddress
+		}
+	}
+	return ErrUnreachable
+}
@@ -0,0 +1,71 @@
+package hdregistry
+
+import (
+	"os"
+	"strconv"
+)
+
+// 实例元数据键名，服务端注册时写入 Nacos Metadata 或 Consul Tags，客户端路由时读取
+const (
+	// MetaVersion 服务版本
+	MetaVersion = "version"
+	// MetaGitSHA 构建对应的 Git 提交
+	MetaGitSHA = "git_sha"
+	// MetaLane 部署泳道，为空或 MetaDefaultLane 时属于默认泳道
+	MetaLane = "lane"
+	// MetaZone 部署可用区
+	MetaZone = "zone"
+	// MetaTenantGroup 租户分组
+	MetaTenantGroup = "tenant_group"
+)
+
+// MetaDefaultLane 默认泳道名称
+const MetaDefaultLane = "default"
+
+// 实例元数据默认值对应的环境变量
+const (
+	EnvServiceVersion = "SERVICE_VERSION"
+	EnvGitSHA         = "GIT_SHA"
+	EnvLane           = "LANE"
+	EnvZone           = "ZONE"
+	EnvTenantGroup    = "TENANT_GROUP"
+	EnvServiceWeight  = "SERVICE_WEIGHT"
+)
+
+// metadataEnvs 元数据键名与环境变量的对应关系
+var metadataEnvs = map[string]string{
+	MetaVersion:     EnvServiceVersion,
+	MetaGitSHA:      EnvGitSHA,
+	MetaLane:        EnvLane,
+	MetaZone:        EnvZone,
+	MetaTenantGroup: EnvTenantGroup,
+}
+
+// MetadataFromEnv 从环境变量读取实例元数据，未设置的环境变量不会出现在结果中
+func MetadataFromEnv() map[string]string {
+	md := make(map[string]string)
+	for key, env := range metadataEnvs {
+		if v := os.Getenv(env); v != "" {
+			md[key] = v
+		}
+	}
+	return md
+}
+
+// MergeMetadata 合并环境变量中的默认元数据和显式设置的元数据，显式设置的优先
+func MergeMetadata(explicit map[string]string) map[string]string {
+	md := MetadataFromEnv()
+	for k, v := range explicit {
+		md[k] = v
+	}
+	return md
+}
+
+// WeightFromEnv 从 SERVICE_WEIGHT 读取实例权重，未设置或无效时返回 0
+func WeightFromEnv() int {
+	w, err := strconv.Atoi(os.Getenv(EnvServiceWeight))
+	if err != nil || w < 0 {
+		return 0
+	}
+	return w
+}
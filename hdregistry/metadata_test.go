@@ -0,0 +1,32 @@
+package hdregistry
+
+import "testing"
+
+func TestMergeMetadata(t *testing.T) {
+	t.Setenv(EnvServiceVersion, "v1.2.0")
+	t.Setenv(EnvLane, "gray")
+	t.Setenv(EnvZone, "")
+
+	md := MergeMetadata(map[string]string{MetaLane: "canary", "owner": "pay"})
+	if md[MetaVersion] != "v1.2.0" {
+		t.Errorf("版本应取自环境变量，实际 %q", md[MetaVersion])
+	}
+	if md[MetaLane] != "canary" {
+		t.Errorf("显式设置的泳道应覆盖环境变量，实际 %q", md[MetaLane])
+	}
+	if md["owner"] != "pay" {
+		t.Errorf("自定义元数据丢失")
+	}
+	if _, ok := md[MetaZone]; ok {
+		t.Errorf("未设置的环境变量不应出现在元数据中")
+	}
+}
+
+func TestWeightFromEnv(t *testing.T) {
+	for env, want := range map[string]int{"": 0, "20": 20, "abc": 0, "-1": 0} {
+		t.Setenv(EnvServiceWeight, env)
+		if got := WeightFromEnv(); got != want {
+			t.Errorf("SERVICE_WEIGHT=%q 期望 %d，实际 %d", env, want, got)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package hdregistry
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/grayscalecloud/kitexcommon/hdmodel"
+	"github.com/nacos-group/nacos-sdk-go/v2/clients"
+	naming_client "github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client"
+	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
+	"github.com/nacos-group/nacos-sdk-go/v2/vo"
+)
+
+// RegistryNacos Nacos 注册中心名称，用于 RegistryError
+const RegistryNacos = "nacos"
+
+const (
+	// DefaultNacosHost 未指定地址时使用的 Nacos 主机
+	DefaultNacosHost = "127.0.0.1"
+	// DefaultNacosPort 未指定端口时使用的 Nacos 端口
+	DefaultNacosPort = 8848
+	// DefaultNacosTimeoutMs 默认请求超时时间（毫秒）
+	DefaultNacosTimeoutMs = 5000
+	// DefaultNacosLogDir 默认日志目录
+	DefaultNacosLogDir = "/tmp/nacos/log"
+	// DefaultNacosCacheDir 默认缓存目录
+	DefaultNacosCacheDir = "/tmp/nacos/cache"
+	// DefaultNacosLogLevel 默认日志级别
+	DefaultNacosLogLevel = "info"
+)
+
+// NacosConfig 各套件、monitor 和 kvconfig 共用的 Nacos 连接配置
+type NacosConfig struct {
+	// Addrs 逗号分隔的服务端地址，支持 host、host:port、[IPv6]:port、裸 IPv6 和 http(s)://host:port/path 形式
+	Addrs string
+	// DefaultPort 地址未指定端口时使用的端口，默认 DefaultNacosPort
+	DefaultPort uint64
+	// ContextPath 服务端上下文路径，地址中带路径时以地址为准
+	ContextPath string
+	// NamespaceId 命名空间 ID
+	NamespaceId string
+	// Username 认证用户名
+	Username string
+	// Password 认证密码
+	Password string
+	// AccessKey 阿里云 MSE 等使用的 AK 认证
+	AccessKey string
+	// SecretKey 阿里云 MSE 等使用的 SK 认证
+	SecretKey string
+	// TLS TLS 配置，启用后地址未指定协议时使用 https
+	TLS hdmodel.RegistryTLS
+	// TimeoutMs 请求超时时间（毫秒），默认 DefaultNacosTimeoutMs
+	TimeoutMs uint64
+	// LogDir 日志目录，默认 DefaultNacosLogDir
+	LogDir string
+	// CacheDir 缓存目录，默认 DefaultNacosCacheDir
+	CacheDir string
+	// LogLevel 日志级别，默认 DefaultNacosLogLevel
+	LogLevel string
+}
+
+// NewNacosConfig 根据注册中心配置创建 Nacos 连接配置
+func NewNacosConfig(reg hdmodel.Registry) NacosConfig {
+	return NacosConfig{
+		Addrs:       reg.RegistryAddress,
+		ContextPath: reg.ContextPath,
+		NamespaceId: reg.NamespaceId,
+		Username:    reg.Username,
+		Password:    reg.Password,
+		AccessKey:   reg.AccessKey,
+		SecretKey:   reg.SecretKey,
+		TLS:         reg.TLS,
+		LogDir:      reg.LogDir,
+		CacheDir:    reg.CacheDir,
+	}
+}
+
+// ServerConfigs 解析服务端地址列表，地址为空时使用 DefaultNacosHost
+func (c NacosConfig) ServerConfigs() ([]constant.ServerConfig, error) {
+	defaultPort := c.DefaultPort
+	if defaultPort == 0 {
+		defaultPort = DefaultNacosPort
+	}
+	defaultScheme := ""
+	if c.TLS.Enable {
+		defaultScheme = "https"
+	}
+
+	var scs []constant.ServerConfig
+	for _, addr := range strings.Split(c.Addrs, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		sc, err := parseNacosServer(addr, defaultPort)
+		if err != nil {
+			return nil, NewInvalidAddressError(RegistryNacos, c.Addrs, err)
+		}
+		if sc.Scheme == "" {
+			sc.Scheme = defaultScheme
+		}
+		if sc.ContextPath == "" {
+			sc.ContextPath = c.ContextPath
+		}
+		scs = append(scs, sc)
+	}
+
+	if len(scs) == 0 {
+		scs = append(scs, constant.ServerConfig{
+			Scheme:      defaultScheme,
+			ContextPath: c.ContextPath,
+			IpAddr:      DefaultNacosHost,
+			Port:        defaultPort,
+		})
+	}
+	return scs, nil
+}
+
+// ClientConfig 返回 Nacos 客户端配置，未设置的字段使用默认值
+func (c NacosConfig) ClientConfig() constant.ClientConfig {
+	cc := constant.ClientConfig{
+		NamespaceId:         c.NamespaceId,
+		TimeoutMs:           c.TimeoutMs,
+		NotLoadCacheAtStart: true,
+		LogDir:              c.LogDir,
+		CacheDir:            c.CacheDir,
+		LogLevel:            c.LogLevel,
+		ContextPath:         c.ContextPath,
+		Username:            c.Username,
+		Password:            c.Password,
+		AccessKey:           c.AccessKey,
+		SecretKey:           c.SecretKey,
+	}
+	if cc.TimeoutMs == 0 {
+		cc.TimeoutMs = DefaultNacosTimeoutMs
+	}
+	if cc.LogDir == "" {
+		cc.LogDir = DefaultNacosLogDir
+	}
+	if cc.CacheDir == "" {
+		cc.CacheDir = DefaultNacosCacheDir
+	}
+	if cc.LogLevel == "" {
+		cc.LogLevel = DefaultNacosLogLevel
+	}
+	if c.TLS.Enable {
+		cc.TLSCfg = constant.TLSConfig{
+			Appointed:          true,
+			Enable:             true,
+			TrustAll:           c.TLS.TrustAll,
+			CaFile:             c.TLS.CaFile,
+			CertFile:           c.TLS.CertFile,
+			KeyFile:            c.TLS.KeyFile,
+			ServerNameOverride: c.TLS.ServerName,
+		}
+	}
+	return cc
+}
+
+// ClientParam 返回创建 Nacos 命名或配置客户端所需的参数
+func (c NacosConfig) ClientParam() (vo.NacosClientParam, error) {
+	scs, err := c.ServerConfigs()
+	if err != nil {
+		return vo.NacosClientParam{}, err
+	}
+	cc := c.ClientConfig()
+	return vo.NacosClientParam{
+		ClientConfig:  &cc,
+		ServerConfigs: scs,
+	}, nil
+}
+
+// NewNacosNamingClient 根据连接配置创建 Nacos 命名客户端
+func NewNacosNamingClient(c NacosConfig) (naming_client.INamingClient, error) {
+	param, err := c.ClientParam()
+	if err != nil {
+		return nil, err
+	}
+	cli, err := clients.NewNamingClient(param)
+	if err != nil {
+		return nil, NewRegistryError(RegistryNacos, c.Addrs, err)
+	}
+	return cli, nil
+}
+
+// FormatNacosAddrs 将服务端地址列表格式化为逗号分隔的地址，可再次被 ServerConfigs 解析
+func FormatNacosAddrs(scs []constant.ServerConfig) string {
+	addrs := make([]string, 0, len(scs))
+	for _, sc := range scs {
+		hostPort := net.JoinHostPort(sc.IpAddr, strconv.FormatUint(sc.Port, 10))
+		if sc.Scheme == "" && sc.ContextPath == "" {
+			addrs = append(addrs, hostPort)
+			continue
+		}
+		scheme := sc.Scheme
+		if scheme == "" {
+			scheme = "http"
+		}
+		u := url.URL{Scheme: scheme, Host: hostPort, Path: sc.ContextPath}
+		addrs = append(addrs, u.String())
+	}
+	return strings.Join(addrs, ",")
+}
+
+// parseNacosServer 解析单个服务端地址
+func parseNacosServer(addr string, defaultPort uint64) (constant.ServerConfig, error) {
+	var sc constant.ServerConfig
+	if strings.Contains(addr, "://") {
+		u, err := url.Parse(addr)
+		if err != nil {
+			return sc, err
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return sc, fmt.Errorf("不支持的协议 '%s'", u.Scheme)
+		}
+		sc.Scheme = u.Scheme
+		sc.ContextPath = strings.TrimSuffix(u.Path, "/")
+		addr = u.Host
+	}
+
+	host, port, err := splitHostPort(addr, defaultPort)
+	if err != nil {
+		return sc, err
+	}
+	sc.IpAddr = host
+	sc.Port = port
+	return sc, nil
+}
+
+// splitHostPort 拆分主机和端口，支持 [IPv6]:port 和不带端口的裸 IPv6
+func splitHostPort(addr string, defaultPort uint64) (string, uint64, error) {
+	var host, port string
+	switch {
+	case strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]"):
+		host = addr[1 : len(addr)-1]
+	case strings.Count(addr, ":") > 1 && !strings.HasPrefix(addr, "["):
+		host = addr
+	case strings.Contains(addr, ":"):
+		var err error
+		host, port, err = net.SplitHostPort(addr)
+		if err != nil {
+			return "", 0, err
+		}
+	default:
+		host = addr
+	}
+
+	if host == "" {
+		host = DefaultNacosHost
+	}
+	if port == "" {
+		return host, defaultPort, nil
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("无效的端口号 '%s': %w", port, err)
+	}
+	return host, p, nil
+}
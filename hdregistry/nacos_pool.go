@@ -0,0 +1,112 @@
+package hdregistry
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+	naming_client "github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client"
+)
+
+// NacosClientPool 进程级 Nacos 命名客户端池
+// 相同服务端地址、命名空间和认证信息的配置共用一个客户端，引用计数归零时关闭客户端
+type NacosClientPool struct {
+	mu      sync.Mutex
+	entries map[string]*nacosPoolEntry
+	// newClient 创建客户端的函数，测试时可替换
+	newClient func(c NacosConfig) (naming_client.INamingClient, error)
+}
+
+type nacosPoolEntry struct {
+	client naming_client.INamingClient
+	refs   int
+}
+
+// NewNacosClientPool 创建 Nacos 命名客户端池
+func NewNacosClientPool() *NacosClientPool {
+	return &NacosClientPool{
+		entries:   make(map[string]*nacosPoolEntry),
+		newClient: NewNacosNamingClient,
+	}
+}
+
+// DefaultNacosClientPool 全局 Nacos 命名客户端池，服务端套件、客户端套件和 monitor 默认使用
+var DefaultNacosClientPool = NewNacosClientPool()
+
+// Acquire 获取命名客户端并增加引用计数，使用完毕后调用 release 释放
+// release 可以重复调用，只有第一次生效；最后一个引用释放时关闭客户端
+func (p *NacosClientPool) Acquire(c NacosConfig) (cli naming_client.INamingClient, release func(), err error) {
+	key, err := nacosPoolKey(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e, ok := p.entries[key]
+	if !ok {
+		cli, err := p.newClient(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		e = &nacosPoolEntry{client: cli}
+		p.entries[key] = e
+	}
+	e.refs++
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			p.release(key, e)
+		})
+	}
+	return e.client, release, nil
+}
+
+// release 减少引用计数，归零时关闭客户端并从池中移除
+func (p *NacosClientPool) release(key string, e *nacosPoolEntry) {
+	p.mu.Lock()
+	e.refs--
+	closing := e.refs == 0
+	if closing && p.entries[key] == e {
+		delete(p.entries, key)
+	}
+	p.mu.Unlock()
+
+	if closing {
+		klog.Infof("关闭 Nacos 命名客户端: %s", strings.SplitN(key, "|", 2)[0])
+		e.client.CloseClient()
+	}
+}
+
+// Len 返回池中的客户端数量
+func (p *NacosClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// AcquireNacosNamingClient 从全局客户端池获取命名客户端
+func AcquireNacosNamingClient(c NacosConfig) (naming_client.INamingClient, func(), error) {
+	return DefaultNacosClientPool.Acquire(c)
+}
+
+// nacosPoolKey 按服务端地址（与顺序无关）、命名空间和认证信息生成池的键
+func nacosPoolKey(c NacosConfig) (string, error) {
+	scs, err := c.ServerConfigs()
+	if err != nil {
+		return "", err
+	}
+	addrs := strings.Split(FormatNacosAddrs(scs), ",")
+	sort.Strings(addrs)
+	return strings.Join([]string{
+		strings.Join(addrs, ","),
+		c.NamespaceId,
+		c.Username,
+		c.Password,
+		c.AccessKey,
+		c.SecretKey,
+	}, "|"), nil
+}
@@ -0,0 +1,77 @@
+package hdregistry
+
+import (
+	"sync/atomic"
+	"testing"
+
+	naming_client "github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client"
+)
+
+// fakeNamingClient 只实现 CloseClient 的命名客户端
+type fakeNamingClient struct {
+	naming_client.INamingClient
+	closed atomic.Int32
+}
+
+func (f *fakeNamingClient) CloseClient() {
+	f.closed.Add(1)
+}
+
+func newFakePool(created *int) *NacosClientPool {
+	p := NewNacosClientPool()
+	p.newClient = func(c NacosConfig) (naming_client.INamingClient, error) {
+		*created++
+		return &fakeNamingClient{}, nil
+	}
+	return p
+}
+
+func TestNacosClientPool_SharesClient(t *testing.T) {
+	var created int
+	p := newFakePool(&created)
+
+	a, releaseA, err := p.Acquire(NacosConfig{Addrs: "10.0.0.1:8848,10.0.0.2", NamespaceId: "dev"})
+	if err != nil {
+		t.Fatalf("获取客户端失败: %v", err)
+	}
+	// 地址顺序不同、端口补全后相同，应复用同一个客户端
+	b, releaseB, err := p.Acquire(NacosConfig{Addrs: "10.0.0.2:8848, 10.0.0.1", NamespaceId: "dev"})
+	if err != nil {
+		t.Fatalf("获取客户端失败: %v", err)
+	}
+	if a != b || created != 1 {
+		t.Fatalf("相同配置应复用客户端，创建了 %d 个", created)
+	}
+
+	// 命名空间不同时使用新的客户端
+	_, releaseC, _ := p.Acquire(NacosConfig{Addrs: "10.0.0.1:8848,10.0.0.2", NamespaceId: "prod"})
+	if created != 2 || p.Len() != 2 {
+		t.Fatalf("不同命名空间应创建新客户端，创建了 %d 个", created)
+	}
+	releaseC()
+
+	fake := a.(*fakeNamingClient)
+	releaseA()
+	releaseA()
+	if fake.closed.Load() != 0 {
+		t.Fatalf("仍有引用时不应关闭客户端")
+	}
+	releaseB()
+	if fake.closed.Load() != 1 {
+		t.Fatalf("最后一个引用释放后应关闭客户端一次，实际 %d 次", fake.closed.Load())
+	}
+	if p.Len() != 0 {
+		t.Fatalf("释放后池应为空，实际 %d", p.Len())
+	}
+}
+
+func TestNacosClientPool_InvalidAddress(t *testing.T) {
+	var created int
+	p := newFakePool(&created)
+	if _, _, err := p.Acquire(NacosConfig{Addrs: "nacos:abc"}); err == nil {
+		t.Fatalf("地址错误时应返回错误")
+	}
+	if created != 0 || p.Len() != 0 {
+		t.Fatalf("地址错误时不应创建客户端")
+	}
+}
@@ -0,0 +1,113 @@
+package hdregistry
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grayscalecloud/kitexcommon/hdmodel"
+	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
+)
+
+func TestNacosConfig_ServerConfigs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  NacosConfig
+		want []constant.ServerConfig
+	}{
+		{
+			name: "空地址使用默认值",
+			cfg:  NacosConfig{},
+			want: []constant.ServerConfig{{IpAddr: DefaultNacosHost, Port: DefaultNacosPort}},
+		},
+		{
+			name: "集群地址",
+			cfg:  NacosConfig{Addrs: "10.0.0.1:8848, 10.0.0.2:8849,10.0.0.3", DefaultPort: 9848},
+			want: []constant.ServerConfig{
+				{IpAddr: "10.0.0.1", Port: 8848},
+				{IpAddr: "10.0.0.2", Port: 8849},
+				{IpAddr: "10.0.0.3", Port: 9848},
+			},
+		},
+		{
+			name: "IPv6 地址",
+			cfg:  NacosConfig{Addrs: "[::1]:8848,fd00::2,[fd00::3]"},
+			want: []constant.ServerConfig{
+				{IpAddr: "::1", Port: 8848},
+				{IpAddr: "fd00::2", Port: DefaultNacosPort},
+				{IpAddr: "fd00::3", Port: DefaultNacosPort},
+			},
+		},
+		{
+			name: "URL 地址和上下文路径",
+			cfg:  NacosConfig{Addrs: "https://nacos.example.com/nacos/,nacos2:8848", ContextPath: "/ctx"},
+			want: []constant.ServerConfig{
+				{Scheme: "https", ContextPath: "/nacos", IpAddr: "nacos.example.com", Port: DefaultNacosPort},
+				{ContextPath: "/ctx", IpAddr: "nacos2", Port: 8848},
+			},
+		},
+		{
+			name: "启用 TLS 默认使用 https",
+			cfg:  NacosConfig{Addrs: "nacos:8848", TLS: hdmodel.RegistryTLS{Enable: true}},
+			want: []constant.ServerConfig{{Scheme: "https", IpAddr: "nacos", Port: 8848}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.ServerConfigs()
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("期望 %d 个地址，实际 %v", len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("第 %d 个地址期望 %+v，实际 %+v", i, tt.want[i], got[i])
+				}
+			}
+
+			// 格式化后可以再次解析为相同的地址
+			again, err := NacosConfig{Addrs: FormatNacosAddrs(got)}.ServerConfigs()
+			if err != nil {
+				t.Fatalf("再次解析失败: %v", err)
+			}
+			for i := range again {
+				if again[i] != got[i] && got[i].Scheme != "" {
+					t.Errorf("格式化后再次解析不一致: %+v != %+v", again[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNacosConfig_InvalidAddress(t *testing.T) {
+	for _, addr := range []string{"nacos:abc", "nacos:70000", "ftp://nacos:8848"} {
+		_, err := NacosConfig{Addrs: addr}.ServerConfigs()
+		if !errors.Is(err, ErrInvalidAddress) {
+			t.Errorf("%s 期望返回 ErrInvalidAddress，实际 %v", addr, err)
+		}
+	}
+}
+
+func TestNacosConfig_ClientConfig(t *testing.T) {
+	cc := NacosConfig{
+		AccessKey: "ak",
+		SecretKey: "sk",
+		LogDir:    "/var/log/nacos",
+		TLS:       hdmodel.RegistryTLS{Enable: true, CaFile: "/etc/ca.pem"},
+	}.ClientConfig()
+
+	if cc.AccessKey != "ak" || cc.SecretKey != "sk" {
+		t.Errorf("AK/SK 未设置: %+v", cc)
+	}
+	if cc.LogDir != "/var/log/nacos" || cc.CacheDir != DefaultNacosCacheDir {
+		t.Errorf("目录配置错误: log=%s cache=%s", cc.LogDir, cc.CacheDir)
+	}
+	if !cc.TLSCfg.Enable || !cc.TLSCfg.Appointed || cc.TLSCfg.CaFile != "/etc/ca.pem" {
+		t.Errorf("TLS 配置错误: %+v", cc.TLSCfg)
+	}
+	if cc.TimeoutMs != DefaultNacosTimeoutMs {
+		t.Errorf("超时时间期望 %d，实际 %d", DefaultNacosTimeoutMs, cc.TimeoutMs)
+	}
+}
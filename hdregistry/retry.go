@@ -0,0 +1,79 @@
+package hdregistry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+)
+
+// RetryPolicy 启动阶段连接注册中心的重试策略
+type RetryPolicy struct {
+	// MaxAttempts 最大尝试次数（包含首次），小于等于 0 时只尝试一次
+	MaxAttempts int
+	// InitialBackoff 首次重试前的等待时间，默认 500 毫秒
+	InitialBackoff time.Duration
+	// MaxBackoff 单次等待时间上限，默认 10 秒
+	MaxBackoff time.Duration
+	// Multiplier 退避倍数，默认 2
+	Multiplier float64
+}
+
+// DefaultRetryPolicy 返回默认重试策略：最多 5 次，指数退避
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+	}
+}
+
+// Do 按重试策略执行 fn，地址错误和认证失败不会重试
+// p 为 nil 时只执行一次
+func (p *RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	if p == nil {
+		return fn()
+	}
+
+	attempts := p.MaxAttempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+	backoff := p.InitialBackoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * time.Second
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	var err error
+	for i := 1; i <= attempts; i++ {
+		if err = fn(); err == nil || !IsRetryable(err) {
+			return err
+		}
+		if i == attempts {
+			break
+		}
+
+		klog.Warnf("连接注册中心失败，%v 后进行第 %d 次重试: %v", backoff, i, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待重试时上下文结束: %w", err)
+		case <-time.After(backoff):
+		}
+
+		backoff = time.Duration(float64(backoff) * multiplier)
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	return fmt.Errorf("已尝试 %d 次: %w", attempts, err)
+}
@@ -0,0 +1,54 @@
+package hdregistry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Do(t *testing.T) {
+	p := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	calls := 0
+	err := p.Do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return NewRegistryError("nacos", "127.0.0.1:8848", errors.New("connection refused"))
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("期望第 3 次成功，实际 calls=%d err=%v", calls, err)
+	}
+
+	calls = 0
+	err = p.Do(context.Background(), func() error {
+		calls++
+		return NewRegistryError("nacos", "127.0.0.1:8848", errors.New("user not found!"))
+	})
+	if !errors.Is(err, ErrAuthFailed) || calls != 1 {
+		t.Fatalf("认证失败不应重试，实际 calls=%d err=%v", calls, err)
+	}
+
+	calls = 0
+	err = p.Do(context.Background(), func() error {
+		calls++
+		return NewRegistryError("consul", "bad", errors.New("dial tcp: i/o timeout"))
+	})
+	if !errors.Is(err, ErrUnreachable) || calls != 3 {
+		t.Fatalf("期望重试 3 次后返回不可达错误，实际 calls=%d err=%v", calls, err)
+	}
+}
+
+func TestNilRetryPolicy(t *testing.T) {
+	var p *RetryPolicy
+	calls := 0
+	_ = p.Do(context.Background(), func() error {
+		calls++
+		return ErrUnreachable
+	})
+	if calls != 1 {
+		t.Fatalf("nil 策略应只执行一次，实际 %d 次", calls)
+	}
+}
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	naming_client "github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client"
+)
+
+// Pinger 支持 PingContext 的连接，如 *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// DBChecker 数据库连接检查，gorm 可通过 db.DB() 获取 *sql.DB
+func DBChecker(db Pinger) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("数据库连接异常: %w", err)
+		}
+		return nil
+	})
+}
+
+// RedisChecker Redis 连接检查
+// ping 通常为 func(ctx context.Context) error { return rdb.Ping(ctx).Err() }
+func RedisChecker(ping func(ctx context.Context) error) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		if err := ping(ctx); err != nil {
+			return fmt.Errorf("redis 连接异常: %w", err)
+		}
+		return nil
+	})
+}
+
+// NacosRegistryChecker Nacos 注册中心连接检查
+func NacosRegistryChecker(cli naming_client.INamingClient) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		if !cli.ServerHealthy() {
+			return errors.New("nacos 注册中心连接异常")
+		}
+		return nil
+	})
+}
+
+// ConsulRegistryChecker Consul 注册中心连接检查，通过查询 leader 判断集群是否可用
+func ConsulRegistryChecker(addr string) (Checker, error) {
+	cli, err := consulapi.NewClient(&consulapi.Config{Address: addr})
+	if err != nil {
+		return nil, fmt.Errorf("创建 Consul 客户端失败: %w", err)
+	}
+	return CheckerFunc(func(ctx context.Context) error {
+		leader, err := cli.Status().LeaderWithQueryOptions((&consulapi.QueryOptions{}).WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("consul 注册中心连接异常: %w", err)
+		}
+		if leader == "" {
+			return errors.New("consul 集群没有 leader")
+		}
+		return nil
+	}), nil
+}
+
+// ConfigFreshness 配置新鲜度检查，超过 MaxAge 未收到配置更新时判定为不健康
+// 在配置监听回调中调用 Touch 记录更新时间
+type ConfigFreshness struct {
+	// MaxAge 允许的最长未更新时间
+	MaxAge      time.Duration
+	lastUpdated atomic.Int64
+}
+
+// NewConfigFreshness 创建配置新鲜度检查，创建时视为刚刚更新
+func NewConfigFreshness(maxAge time.Duration) *ConfigFreshness {
+	f := &ConfigFreshness{MaxAge: maxAge}
+	f.Touch()
+	return f
+}
+
+// Touch 记录配置更新时间
+func (f *ConfigFreshness) Touch() {
+	f.lastUpdated.Store(time.Now().UnixNano())
+}
+
+// LastUpdated 返回最近一次配置更新时间
+func (f *ConfigFreshness) LastUpdated() time.Time {
+	return time.Unix(0, f.lastUpdated.Load())
+}
+
+// Check 实现 Checker 接口
+func (f *ConfigFreshness) Check(ctx context.Context) error {
+	if f.MaxAge <= 0 {
+		return nil
+	}
+	if age := time.Since(f.LastUpdated()); age > f.MaxAge {
+		return fmt.Errorf("配置已 %v 未更新，超过阈值 %v", age.Truncate(time.Second), f.MaxAge)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+// Package health 提供服务健康检查与就绪状态管理
+// 存活检查（liveness）用于判断进程是否需要重启，就绪检查（readiness）用于判断实例是否可以接收流量
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status 健康状态
+type Status string
+
+const (
+	// StatusUp 健康
+	StatusUp Status = "UP"
+	// StatusDown 不健康
+	StatusDown Status = "DOWN"
+)
+
+// Kind 检查类型
+type Kind int
+
+const (
+	// KindLiveness 存活检查，失败时进程需要重启
+	KindLiveness Kind = iota + 1
+	// KindReadiness 就绪检查，失败时实例暂停接收流量
+	KindReadiness
+)
+
+// DefaultCheckTimeout 单个检查项的默认超时时间
+const DefaultCheckTimeout = 3 * time.Second
+
+// Checker 健康检查项
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc 函数形式的健康检查项
+type CheckerFunc func(ctx context.Context) error
+
+// Check 实现 Checker 接口
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result 单个检查项的结果
+type Result struct {
+	Name     string `json:"name"`
+	Status   Status `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report 健康检查报告
+type Report struct {
+	Status Status   `json:"status"`
+	Checks []Result `json:"checks,omitempty"`
+}
+
+type entry struct {
+	kind    Kind
+	checker Checker
+}
+
+// Registry 健康检查注册表
+type Registry struct {
+	mu       sync.RWMutex
+	checkers map[string]entry
+	// ready 手动就绪开关，优雅退出时会被置为 false
+	ready atomic.Bool
+	// Timeout 单个检查项的超时时间
+	Timeout time.Duration
+}
+
+// NewRegistry 创建健康检查注册表，初始状态为就绪
+func NewRegistry() *Registry {
+	r := &Registry{
+		checkers: make(map[string]entry),
+		Timeout:  DefaultCheckTimeout,
+	}
+	r.ready.Store(true)
+	return r
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default 返回全局健康检查注册表
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register 注册检查项，同名检查项会被覆盖
+func (r *Registry) Register(name string, kind Kind, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers[name] = entry{kind: kind, checker: checker}
+}
+
+// Unregister 注销检查项
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checkers, name)
+}
+
+// SetReady 设置就绪开关，为 false 时就绪检查直接返回不健康
+func (r *Registry) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+// IsReady 返回就绪开关状态
+func (r *Registry) IsReady() bool {
+	return r.ready.Load()
+}
+
+// Liveness 执行所有存活检查
+func (r *Registry) Liveness(ctx context.Context) Report {
+	return r.run(ctx, func(k Kind) bool { return k == KindLiveness })
+}
+
+// Readiness 执行所有存活检查和就绪检查，就绪开关关闭时直接返回不健康
+func (r *Registry) Readiness(ctx context.Context) Report {
+	if !r.IsReady() {
+		return Report{
+			Status: StatusDown,
+			Checks: []Result{{Name: "ready", Status: StatusDown, Error: "服务正在关闭"}},
+		}
+	}
+	return r.run(ctx, func(Kind) bool { return true })
+}
+
+// run 并发执行符合条件的检查项
+func (r *Registry) run(ctx context.Context, match func(Kind) bool) Report {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.checkers))
+	for name, e := range r.checkers {
+		if match(e.kind) {
+			names = append(names, name)
+		}
+	}
+	checkers := make(map[string]Checker, len(names))
+	for _, name := range names {
+		checkers[name] = r.checkers[name].checker
+	}
+	r.mu.RUnlock()
+	sort.Strings(names)
+
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string, c Checker) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, name, c, timeout)
+		}(i, name, checkers[name])
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: results}
+	for _, res := range results {
+		if res.Status != StatusUp {
+			report.Status = StatusDown
+			break
+		}
+	}
+	return report
+}
+
+// runCheck 执行单个检查项，带超时和 panic 保护
+func runCheck(ctx context.Context, name string, c Checker, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	res := Result{Name: name, Status: StatusUp}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("检查发生 panic: %v", p)
+			}
+		}()
+		done <- c.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("检查超时（%v）", timeout)
+	}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	res.Duration = time.Since(start).String()
+	return res
+}
+
+// Register 在全局注册表中注册检查项
+func Register(name string, kind Kind, checker Checker) {
+	defaultRegistry.Register(name, kind, checker)
+}
+
+// SetReady 设置全局注册表的就绪开关
+func SetReady(ready bool) {
+	defaultRegistry.SetReady(ready)
+}
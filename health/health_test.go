@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRegistry_ReadinessAndLiveness(t *testing.T) {
+	r := NewRegistry()
+	r.Register("db", KindLiveness, CheckerFunc(func(ctx context.Context) error { return nil }))
+
+	redisErr := errors.New("connection refused")
+	var redisDown bool
+	r.Register("redis", KindReadiness, RedisChecker(func(ctx context.Context) error {
+		if redisDown {
+			return redisErr
+		}
+		return nil
+	}))
+
+	if rep := r.Readiness(context.Background()); rep.Status != StatusUp || len(rep.Checks) != 2 {
+		t.Fatalf("期望就绪，实际 %+v", rep)
+	}
+
+	redisDown = true
+	if rep := r.Readiness(context.Background()); rep.Status != StatusDown {
+		t.Fatalf("redis 不可用时应未就绪，实际 %+v", rep)
+	}
+	if rep := r.Liveness(context.Background()); rep.Status != StatusUp || len(rep.Checks) != 1 {
+		t.Fatalf("就绪检查失败不应影响存活检查，实际 %+v", rep)
+	}
+
+	redisDown = false
+	r.SetReady(false)
+	if rep := r.Readiness(context.Background()); rep.Status != StatusDown {
+		t.Fatalf("就绪开关关闭后应未就绪，实际 %+v", rep)
+	}
+}
+
+func TestRegistry_CheckTimeout(t *testing.T) {
+	r := NewRegistry()
+	r.Timeout = 10 * time.Millisecond
+	r.Register("slow", KindLiveness, CheckerFunc(func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}))
+
+	if rep := r.Liveness(context.Background()); rep.Status != StatusDown {
+		t.Fatalf("超时的检查项应判定为不健康，实际 %+v", rep)
+	}
+}
+
+func TestConfigFreshness(t *testing.T) {
+	f := NewConfigFreshness(time.Minute)
+	if err := f.Check(context.Background()); err != nil {
+		t.Fatalf("刚创建时应视为新鲜: %v", err)
+	}
+	f.lastUpdated.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	if err := f.Check(context.Background()); err == nil {
+		t.Fatalf("超过 MaxAge 未更新应返回错误")
+	}
+}
+
+func TestRegisterHTTP(t *testing.T) {
+	r := NewRegistry()
+	mux := http.NewServeMux()
+	RegisterHTTP(mux, r)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("期望 200，实际 %d", rec.Code)
+	}
+
+	r.SetReady(false)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("期望 503，实际 %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, LivenessPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("存活检查不受就绪开关影响，期望 200，实际 %d", rec.Code)
+	}
+}
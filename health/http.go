@@ -0,0 +1,33 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	// LivenessPath 存活检查 HTTP 路径
+	LivenessPath = "/healthz"
+	// ReadinessPath 就绪检查 HTTP 路径
+	ReadinessPath = "/readyz"
+)
+
+// RegisterHTTP 在 mux 上注册存活和就绪检查接口，健康时返回 200，否则返回 503
+func RegisterHTTP(mux *http.ServeMux, r *Registry) {
+	mux.HandleFunc(LivenessPath, func(w http.ResponseWriter, req *http.Request) {
+		writeReport(w, r.Liveness(req.Context()))
+	})
+	mux.HandleFunc(ReadinessPath, func(w http.ResponseWriter, req *http.Request) {
+		writeReport(w, r.Readiness(req.Context()))
+	})
+}
+
+func writeReport(w http.ResponseWriter, report Report) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if report.Status == StatusUp {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/kitex/pkg/generic"
+	"github.com/cloudwego/kitex/server"
+)
+
+// healthIDL 通用健康检查服务定义，调用方可通过泛化调用 HealthService.HealthCheck
+const healthIDL = `
+namespace go health
+
+struct HealthCheckRequest {
+    1: string type // liveness 或 readiness，默认 readiness
+}
+
+struct HealthCheckResult {
+    1: string name
+    2: string status
+    3: string error
+}
+
+struct HealthCheckResponse {
+    1: string status
+    2: list<HealthCheckResult> checks
+}
+
+service HealthService {
+    HealthCheckResponse HealthCheck(1: HealthCheckRequest req)
+}
+`
+
+const (
+	// CheckTypeLiveness 泛化调用中请求存活检查
+	CheckTypeLiveness = "liveness"
+	// CheckTypeReadiness 泛化调用中请求就绪检查
+	CheckTypeReadiness = "readiness"
+)
+
+// RegisterKitexService 在 Kitex 服务器上注册泛化健康检查服务 HealthService
+// 需要在 svr.Run() 之前调用
+func RegisterKitexService(svr server.Server, r *Registry) error {
+	p, err := generic.NewThriftContentProvider(healthIDL, nil)
+	if err != nil {
+		return fmt.Errorf("解析健康检查 IDL 失败: %w", err)
+	}
+	g, err := generic.MapThriftGeneric(p)
+	if err != nil {
+		return fmt.Errorf("创建健康检查泛化服务失败: %w", err)
+	}
+	if err := svr.RegisterService(generic.ServiceInfoWithGeneric(g), &kitexHealthService{registry: r}); err != nil {
+		return fmt.Errorf("注册健康检查服务失败: %w", err)
+	}
+	return nil
+}
+
+// kitexHealthService 实现 generic.Service
+type kitexHealthService struct {
+	registry *Registry
+}
+
+// GenericCall 处理 HealthCheck 泛化调用
+func (s *kitexHealthService) GenericCall(ctx context.Context, method string, request interface{}) (interface{}, error) {
+	if method != "HealthCheck" {
+		return nil, fmt.Errorf("未知的健康检查方法: %s", method)
+	}
+
+	var report Report
+	if checkType(request) == CheckTypeLiveness {
+		report = s.registry.Liveness(ctx)
+	} else {
+		report = s.registry.Readiness(ctx)
+	}
+
+	checks := make([]interface{}, 0, len(report.Checks))
+	for _, c := range report.Checks {
+		checks = append(checks, map[string]interface{}{
+			"name":   c.Name,
+			"status": string(c.Status),
+			"error":  c.Error,
+		})
+	}
+	return map[string]interface{}{
+		"status": string(report.Status),
+		"checks": checks,
+	}, nil
+}
+
+// checkType 从泛化请求中读取检查类型
+func checkType(request interface{}) string {
+	m, ok := request.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if req, ok := m["req"].(map[string]interface{}); ok {
+		m = req
+	}
+	t, _ := m["type"].(string)
+	return t
+}
@@ -220,6 +220,24 @@ func (f *ConfigFactory) GetKvConfig(dataId, group string) (string, error) {
 	}
 }
 
+// ListenConfig 监听配置变化，配置变更时回调最新内容
+func (f *ConfigFactory) ListenConfig(dataId, group string, callback func(content string)) error {
+	switch f.configType {
+	case ConfigTypeNacos:
+		if f.nacosClient == nil {
+			return fmt.Errorf("nacos 客户端未初始化")
+		}
+		return f.nacosClient.ListenConfig(dataId, group, callback)
+	case ConfigTypeConsul:
+		if f.consulClient == nil {
+			return fmt.Errorf("consul 客户端未初始化")
+		}
+		return f.consulClient.ListenConfig(dataId, group, callback)
+	default:
+		return fmt.Errorf("不支持的配置类型: %s", f.configType)
+	}
+}
+
 // GetPasetoPubConfig 获取 Paseto 公钥配置（兼容接口）
 func (f *ConfigFactory) GetPasetoPubConfig(group string) (*hdmodel.PasetoConfig, error) {
 	switch f.configType {
@@ -3,10 +3,12 @@ package kvconfig
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cloudwego/kitex/pkg/klog"
 	"github.com/grayscalecloud/kitexcommon/hdmodel"
+	"github.com/grayscalecloud/kitexcommon/hdregistry"
 	"github.com/nacos-group/nacos-sdk-go/v2/clients"
 	"github.com/nacos-group/nacos-sdk-go/v2/clients/config_client"
 	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
@@ -28,48 +30,20 @@ type NacosConfigClient struct {
 
 // NewNacosConfigClient 创建 Nacos 配置客户端
 func NewNacosConfigClient(serverAddrs []string, namespaceId, group string, username, password string) (*NacosConfigClient, error) {
-	// 默认配置
-	clientConfig := constant.ClientConfig{
-		NamespaceId:         namespaceId,
-		TimeoutMs:           5000,
-		NotLoadCacheAtStart: true,
-		LogDir:              "/tmp/nacos/log",
-		CacheDir:            "/tmp/nacos/cache",
-		LogLevel:            "info",
+	nacosCfg := hdregistry.NacosConfig{
+		Addrs:       strings.Join(serverAddrs, ","),
+		NamespaceId: namespaceId,
 		// 添加身份验证支持
 		Username: username,
 		Password: password,
 	}
-
-	// 服务器配置
-	serverConfigs := make([]constant.ServerConfig, 0, len(serverAddrs))
-	for _, addr := range serverAddrs {
-		// 解析地址，支持 IP:Port 格式
-		var ipAddr string
-		var port uint64 = 8848
-
-		if len(addr) > 0 {
-			// 简单的地址解析，假设格式为 IP:Port
-			if len(addr) > 5 && addr[len(addr)-5:] == ":8848" {
-				ipAddr = addr[:len(addr)-5]
-			} else {
-				ipAddr = addr
-			}
-		}
-
-		serverConfigs = append(serverConfigs, constant.ServerConfig{
-			IpAddr: ipAddr,
-			Port:   port,
-		})
+	param, err := nacosCfg.ClientParam()
+	if err != nil {
+		return nil, fmt.Errorf("创建 Nacos 配置客户端失败: %w", err)
 	}
 
 	// 创建配置客户端
-	configClient, err := clients.NewConfigClient(
-		vo.NacosClientParam{
-			ClientConfig:  &clientConfig,
-			ServerConfigs: serverConfigs,
-		},
-	)
+	configClient, err := clients.NewConfigClient(param)
 	if err != nil {
 		return nil, fmt.Errorf("创建 Nacos 配置客户端失败: %w", err)
 	}
@@ -77,12 +51,24 @@ func NewNacosConfigClient(serverAddrs []string, namespaceId, group string, usern
 	return &NacosConfigClient{
 		client: configClient,
 		config: &NacosConfig{
-			ServerConfigs: serverConfigs,
-			ClientConfig:  clientConfig,
+			ServerConfigs: param.ServerConfigs,
+			ClientConfig:  *param.ClientConfig,
 		},
 	}, nil
 }
 
+// NewNacosConfigClientWithRegistry 使用与注册中心相同的连接配置创建 Nacos 配置客户端，支持集群地址、TLS 和 AK/SK 认证
+func NewNacosConfigClientWithRegistry(nacosCfg hdregistry.NacosConfig) (*NacosConfigClient, error) {
+	param, err := nacosCfg.ClientParam()
+	if err != nil {
+		return nil, fmt.Errorf("创建 Nacos 配置客户端失败: %w", err)
+	}
+	return NewNacosConfigClientWithConfig(&NacosConfig{
+		ServerConfigs: param.ServerConfigs,
+		ClientConfig:  *param.ClientConfig,
+	})
+}
+
 // NewNacosConfigClientWithConfig 使用自定义配置创建 Nacos 配置客户端
 func NewNacosConfigClientWithConfig(config *NacosConfig) (*NacosConfigClient, error) {
 	configClient, err := clients.NewConfigClient(
@@ -0,0 +1,276 @@
+// Package lifecycle 管理服务组件的启动和关闭顺序
+// 组件声明依赖后按拓扑顺序启动、按相反顺序关闭，每个组件有独立的超时时间
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+)
+
+const (
+	// DefaultStartTimeout 组件启动的默认超时时间
+	DefaultStartTimeout = 30 * time.Second
+	// DefaultStopTimeout 组件关闭的默认超时时间
+	DefaultStopTimeout = 10 * time.Second
+)
+
+// Component 生命周期组件
+type Component interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// Hook 函数形式的组件，OnStart 或 OnStop 为 nil 时对应阶段不做任何事
+type Hook struct {
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Start 实现 Component 接口
+func (h Hook) Start(ctx context.Context) error {
+	if h.OnStart == nil {
+		return nil
+	}
+	return h.OnStart(ctx)
+}
+
+// Stop 实现 Component 接口
+func (h Hook) Stop(ctx context.Context) error {
+	if h.OnStop == nil {
+		return nil
+	}
+	return h.OnStop(ctx)
+}
+
+// Option 组件注册选项
+type Option func(n *node)
+
+// DependsOn 声明依赖的组件，依赖会先于当前组件启动、晚于当前组件关闭
+func DependsOn(names ...string) Option {
+	return func(n *node) {
+		n.deps = append(n.deps, names...)
+	}
+}
+
+// StartTimeout 设置组件启动超时时间
+func StartTimeout(d time.Duration) Option {
+	return func(n *node) {
+		n.startTimeout = d
+	}
+}
+
+// StopTimeout 设置组件关闭超时时间
+func StopTimeout(d time.Duration) Option {
+	return func(n *node) {
+		n.stopTimeout = d
+	}
+}
+
+// Started 标记组件在注册时已经启动，Start 时跳过，Stop 时正常关闭
+func Started() Option {
+	return func(n *node) {
+		n.started = true
+	}
+}
+
+type node struct {
+	name         string
+	component    Component
+	deps         []string
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+	started      bool
+	index        int
+}
+
+// Lifecycle 组件生命周期管理器
+type Lifecycle struct {
+	mu    sync.Mutex
+	nodes map[string]*node
+}
+
+// New 创建生命周期管理器
+func New() *Lifecycle {
+	return &Lifecycle{nodes: make(map[string]*node)}
+}
+
+var defaultLifecycle = New()
+
+// Default 返回全局生命周期管理器，服务端套件和 monitor 默认注册到这里
+func Default() *Lifecycle {
+	return defaultLifecycle
+}
+
+// Register 注册组件，组件名称不能重复
+func (l *Lifecycle) Register(name string, c Component, opts ...Option) error {
+	n := &node{
+		name:         name,
+		component:    c,
+		startTimeout: DefaultStartTimeout,
+		stopTimeout:  DefaultStopTimeout,
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.nodes[name]; ok {
+		return fmt.Errorf("组件 %s 已注册", name)
+	}
+	n.index = len(l.nodes)
+	l.nodes[name] = n
+	return nil
+}
+
+// MustRegister 注册组件，失败时 panic
+func (l *Lifecycle) MustRegister(name string, c Component, opts ...Option) {
+	if err := l.Register(name, c, opts...); err != nil {
+		panic(err)
+	}
+}
+
+// Start 按依赖的拓扑顺序启动所有未启动的组件
+// 任一组件启动失败时，按相反顺序关闭本次已启动的组件并返回错误
+func (l *Lifecycle) Start(ctx context.Context) error {
+	ordered, err := l.sorted()
+	if err != nil {
+		return err
+	}
+
+	var started []*node
+	for _, n := range ordered {
+		if l.isStarted(n) {
+			continue
+		}
+		klog.Infof("启动组件 %s...", n.name)
+		if err := runWithTimeout(ctx, n.startTimeout, n.component.Start); err != nil {
+			startErr := fmt.Errorf("启动组件 %s 失败: %w", n.name, err)
+			if stopErr := l.stopNodes(ctx, started); stopErr != nil {
+				return errors.Join(startErr, stopErr)
+			}
+			return startErr
+		}
+		l.setStarted(n, true)
+		started = append(started, n)
+	}
+	return nil
+}
+
+// Stop 按启动的相反顺序关闭所有已启动的组件，单个组件失败不影响其他组件，返回合并后的错误
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	ordered, err := l.sorted()
+	if err != nil {
+		return err
+	}
+	return l.stopNodes(ctx, ordered)
+}
+
+// stopNodes 按相反顺序关闭已启动的组件
+func (l *Lifecycle) stopNodes(ctx context.Context, nodes []*node) error {
+	var errs []error
+	for i := len(nodes) - 1; i >= 0; i-- {
+		n := nodes[i]
+		if !l.isStarted(n) {
+			continue
+		}
+		klog.Infof("关闭组件 %s...", n.name)
+		if err := runWithTimeout(ctx, n.stopTimeout, n.component.Stop); err != nil {
+			klog.Errorf("关闭组件 %s 失败: %v", n.name, err)
+			errs = append(errs, fmt.Errorf("关闭组件 %s 失败: %w", n.name, err))
+		}
+		l.setStarted(n, false)
+	}
+	return errors.Join(errs...)
+}
+
+// sorted 按依赖关系拓扑排序，无依赖关系的组件保持注册顺序
+func (l *Lifecycle) sorted() ([]*node, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	indegree := make(map[string]int, len(l.nodes))
+	dependents := make(map[string][]*node, len(l.nodes))
+	for _, n := range l.nodes {
+		indegree[n.name] += 0
+		for _, dep := range n.deps {
+			if _, ok := l.nodes[dep]; !ok {
+				return nil, fmt.Errorf("组件 %s 依赖的组件 %s 未注册", n.name, dep)
+			}
+			indegree[n.name]++
+			dependents[dep] = append(dependents[dep], n)
+		}
+	}
+
+	var ready []*node
+	for _, n := range l.nodes {
+		if indegree[n.name] == 0 {
+			ready = append(ready, n)
+		}
+	}
+
+	ordered := make([]*node, 0, len(l.nodes))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return ready[i].index < ready[j].index })
+		n := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, n)
+		for _, d := range dependents[n.name] {
+			indegree[d.name]--
+			if indegree[d.name] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	if len(ordered) != len(l.nodes) {
+		return nil, errors.New("组件之间存在循环依赖")
+	}
+	return ordered, nil
+}
+
+func (l *Lifecycle) isStarted(n *node) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return n.started
+}
+
+func (l *Lifecycle) setStarted(n *node, started bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n.started = started
+}
+
+// runWithTimeout 在超时时间内执行 fn，带 panic 保护
+func runWithTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("发生 panic: %v", r)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("执行超时（%v）: %w", timeout, ctx.Err())
+	}
+}
+
+// Register 在全局生命周期管理器中注册组件
+func Register(name string, c Component, opts ...Option) error {
+	return defaultLifecycle.Register(name, c, opts...)
+}
@@ -0,0 +1,107 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func recorder(events *[]string, name string) Hook {
+	return Hook{
+		OnStart: func(ctx context.Context) error {
+			*events = append(*events, "start:"+name)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			*events = append(*events, "stop:"+name)
+			return nil
+		},
+	}
+}
+
+func TestLifecycle_StartStopOrder(t *testing.T) {
+	var events []string
+	l := New()
+	l.MustRegister("server", recorder(&events, "server"), DependsOn("db", "config"))
+	l.MustRegister("db", recorder(&events, "db"), DependsOn("config"))
+	l.MustRegister("config", recorder(&events, "config"))
+	l.MustRegister("metrics", recorder(&events, "metrics"), Started())
+
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatalf("启动失败: %v", err)
+	}
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatalf("关闭失败: %v", err)
+	}
+
+	want := []string{
+		"start:config", "start:db", "start:server",
+		"stop:metrics", "stop:server", "stop:db", "stop:config",
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("顺序错误\n期望: %v\n实际: %v", want, events)
+	}
+
+	// 重复关闭不应再次执行
+	events = nil
+	if err := l.Stop(context.Background()); err != nil || len(events) != 0 {
+		t.Fatalf("重复关闭不应执行任何组件，实际 %v err=%v", events, err)
+	}
+}
+
+func TestLifecycle_StartFailureRollsBack(t *testing.T) {
+	var events []string
+	l := New()
+	l.MustRegister("db", recorder(&events, "db"))
+	l.MustRegister("mq", Hook{OnStart: func(ctx context.Context) error {
+		return errors.New("broker unavailable")
+	}}, DependsOn("db"))
+
+	err := l.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "mq") {
+		t.Fatalf("期望 mq 启动失败，实际 %v", err)
+	}
+	want := []string{"start:db", "stop:db"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("启动失败后应回滚已启动组件\n期望: %v\n实际: %v", want, events)
+	}
+}
+
+func TestLifecycle_StopCollectsErrors(t *testing.T) {
+	l := New()
+	l.MustRegister("a", Hook{OnStop: func(ctx context.Context) error { return errors.New("a failed") }})
+	l.MustRegister("b", Hook{OnStop: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}, StopTimeout(10*time.Millisecond))
+
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatalf("启动失败: %v", err)
+	}
+	err := l.Stop(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "a failed") || !strings.Contains(err.Error(), "组件 b") {
+		t.Fatalf("期望合并两个组件的错误，实际 %v", err)
+	}
+}
+
+func TestLifecycle_InvalidDependencies(t *testing.T) {
+	l := New()
+	l.MustRegister("a", Hook{}, DependsOn("missing"))
+	if err := l.Start(context.Background()); err == nil {
+		t.Fatalf("依赖未注册的组件应返回错误")
+	}
+
+	l = New()
+	l.MustRegister("a", Hook{}, DependsOn("b"))
+	l.MustRegister("b", Hook{}, DependsOn("a"))
+	if err := l.Start(context.Background()); err == nil {
+		t.Fatalf("循环依赖应返回错误")
+	}
+
+	if err := l.Register("a", Hook{}); err == nil {
+		t.Fatalf("重复注册应返回错误")
+	}
+}
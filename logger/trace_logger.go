@@ -8,7 +8,7 @@ import (
 	"runtime"
 
 	"github.com/cloudwego/kitex/pkg/klog"
-	"github.com/grayscalecloud/kitexcommon/tools"
+	"github.com/grayscalecloud/kitexcommon/ctxx"
 
 	kitexlogrus "github.com/kitex-contrib/obs-opentelemetry/logging/logrus"
 	"go.opentelemetry.io/otel/attribute"
@@ -64,7 +64,7 @@ func getCallerInfo(skip int, projectRoot string) string {
 func (l *TraceLogger) logWithTrace(ctx context.Context, level, msg string) {
 	caller := getCallerInfo(4, l.prefix)
 	span := trace.SpanFromContext(ctx)
-	tenantId := tools.GetTenant(ctx)
+	tenantId := ctxx.GetTenantID(ctx)
 
 	if span.IsRecording() {
 		// 添加错误处理，确保属性添加不会失败
@@ -19,13 +19,14 @@ import (
 	"fmt"
 	"net"
 	"net/http"
-	"strconv"
+	"sync"
 
 	"github.com/cloudwego/kitex/pkg/klog"
 	"github.com/grayscalecloud/kitexcommon/hdmodel"
+	"github.com/grayscalecloud/kitexcommon/hdregistry"
+	"github.com/grayscalecloud/kitexcommon/health"
+	"github.com/grayscalecloud/kitexcommon/lifecycle"
 	"github.com/grayscalecloud/kitexcommon/utils"
-	"github.com/nacos-group/nacos-sdk-go/v2/clients"
-	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
 	"github.com/nacos-group/nacos-sdk-go/v2/vo"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
@@ -34,6 +35,14 @@ import (
 
 var Reg *prometheus.Registry
 
+// 注册到 lifecycle.Default() 的组件名称，其他组件可以通过 lifecycle.DependsOn 声明依赖
+const (
+	// ComponentMetricsServer metrics HTTP 服务
+	ComponentMetricsServer = "metrics_server"
+	// ComponentMetricsRegistration metrics 实例在 Nacos 上的注册，先于 metrics HTTP 服务关闭
+	ComponentMetricsRegistration = "metrics_registration"
+)
+
 func initMetric(serverName string, cfg *hdmodel.Monitor) CtxCallback {
 
 	if cfg.Prometheus.Enable {
@@ -46,37 +55,8 @@ func initMetric(serverName string, cfg *hdmodel.Monitor) CtxCallback {
 	Reg.MustRegister(collectors.NewGoCollector())
 	Reg.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
 
-	// 解析Nacos服务器地址和端口
-	host, port, err := net.SplitHostPort(cfg.Registry.RegistryAddress)
-	if err != nil {
-		klog.Error("解析Nacos服务器地址失败:", err)
-		return func(ctx context.Context) {}
-	}
-	portInt, _ := strconv.Atoi(port)
-
-	// Nacos配置
-	sc := []constant.ServerConfig{
-		*constant.NewServerConfig(host, uint64(portInt)),
-	}
-
-	cc := constant.ClientConfig{
-		NamespaceId:         cfg.Registry.NamespaceId,
-		Username:            cfg.Registry.Username,
-		Password:            cfg.Registry.Password,
-		TimeoutMs:           5000,
-		NotLoadCacheAtStart: true,
-		LogDir:              "/tmp/nacos/log",
-		CacheDir:            "/tmp/nacos/cache",
-		LogLevel:            "info",
-	}
-
-	// 创建Nacos客户端
-	client, err := clients.NewNamingClient(
-		vo.NacosClientParam{
-			ClientConfig:  &cc,
-			ServerConfigs: sc,
-		},
-	)
+	// 从全局客户端池获取Nacos客户端，与服务端套件共用连接
+	client, release, err := hdregistry.AcquireNacosNamingClient(hdregistry.NewNacosConfig(cfg.Registry))
 	if err != nil {
 		klog.Error("创建Nacos客户端失败:", err)
 		return func(ctx context.Context) {}
@@ -91,6 +71,7 @@ func initMetric(serverName string, cfg *hdmodel.Monitor) CtxCallback {
 	_, err = net.ResolveTCPAddr("tcp", metricsAddr)
 	if err != nil {
 		klog.Error("解析metrics地址失败:", err)
+		release()
 		return func(ctx context.Context) {}
 	}
 
@@ -114,24 +95,58 @@ func initMetric(serverName string, cfg *hdmodel.Monitor) CtxCallback {
 		klog.Error("注册服务到Nacos失败:", err)
 	}
 
-	// 启动metrics服务
+	// 注册中心连接作为就绪检查项
+	health.Register("nacos_registry", health.KindReadiness, health.NacosRegistryChecker(client))
+
+	// 启动metrics服务，同时提供 /healthz 和 /readyz 健康检查接口
 	http.Handle("/metrics", promhttp.HandlerFor(Reg, promhttp.HandlerOpts{}))
+	health.RegisterHTTP(http.DefaultServeMux, health.Default())
+	srv := &http.Server{Addr: metricsAddr}
 	go func() {
-		err := http.ListenAndServe(metricsAddr, nil)
-		if err != nil {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			klog.Error("启动metrics服务失败:", err)
 		}
 	}()
 
+	// 取消注册函数，可能同时被返回的回调和生命周期管理器调用，只执行一次
+	var deregisterOnce sync.Once
+	deregister := func(ctx context.Context) error {
+		var err error
+		deregisterOnce.Do(func() {
+			_, err = client.DeregisterInstance(vo.DeregisterInstanceParam{
+				Ip:          localIp,
+				Port:        uint64(cfg.Prometheus.MetricsPort),
+				ServiceName: serviceName,
+				GroupName:   cfg.Registry.Group,
+				Ephemeral:   true,
+			})
+		})
+		return err
+	}
+
+	// 注册到生命周期管理器，关闭时先注销实例再停止 metrics 服务
+	registerComponent(ComponentMetricsServer, lifecycle.Hook{
+		OnStop: srv.Shutdown,
+	})
+	registerComponent(ComponentMetricsRegistration, lifecycle.Hook{
+		OnStop: func(ctx context.Context) error {
+			defer release()
+			return deregister(ctx)
+		},
+	}, lifecycle.DependsOn(ComponentMetricsServer))
+
 	// 返回取消注册函数
 	return func(ctx context.Context) {
-		// 取消注册服务
-		_, err = client.DeregisterInstance(vo.DeregisterInstanceParam{
-			Ip:          localIp,
-			Port:        uint64(cfg.Prometheus.MetricsPort),
-			ServiceName: serviceName,
-			GroupName:   cfg.Registry.Group,
-			Ephemeral:   true,
-		})
+		if err := deregister(ctx); err != nil {
+			klog.Error("从Nacos注销metrics服务失败:", err)
+		}
+	}
+}
+
+// registerComponent 将已启动的组件注册到全局生命周期管理器
+func registerComponent(name string, c lifecycle.Component, opts ...lifecycle.Option) {
+	opts = append(opts, lifecycle.Started())
+	if err := lifecycle.Register(name, c, opts...); err != nil {
+		klog.Warnf("注册生命周期组件失败: %v", err)
 	}
 }
@@ -0,0 +1,434 @@
+package serversuite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/klog"
+	kitexregistry "github.com/cloudwego/kitex/pkg/registry"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/server"
+	"github.com/grayscalecloud/kitexcommon/hdmodel"
+	"github.com/grayscalecloud/kitexcommon/hdregistry"
+	"github.com/grayscalecloud/kitexcommon/lifecycle"
+	"github.com/grayscalecloud/kitexcommon/monitor"
+	prometheus "github.com/kitex-contrib/monitor-prometheus"
+	"github.com/kitex-contrib/obs-opentelemetry/provider"
+	"github.com/kitex-contrib/obs-opentelemetry/tracing"
+	registryconsul "github.com/kitex-contrib/registry-consul"
+	"github.com/kitex-contrib/registry-nacos/v2/registry"
+)
+
+// RegistryKind 注册中心类型
+type RegistryKind string
+
+const (
+	// RegistryNone 不注册到任何注册中心
+	RegistryNone RegistryKind = "none"
+	// RegistryConsul 使用 Consul 注册中心
+	RegistryConsul RegistryKind = "consul"
+	// RegistryNacos 使用 Nacos 注册中心
+	RegistryNacos RegistryKind = "nacos"
+)
+
+// 注册到生命周期管理器的组件名称
+const (
+	// ComponentRegistryClient 注册中心客户端，Kitex 服务停止并注销实例后关闭
+	ComponentRegistryClient = "registry_client"
+	// ComponentOTelProvider OpenTelemetry Provider，关闭时上报剩余数据
+	ComponentOTelProvider = "otel_provider"
+	// ComponentLoadShedder 自适应降载器的 CPU 采样
+	ComponentLoadShedder = "load_shedder"
+)
+
+// 构建阶段名称，按以下顺序依次执行
+const (
+	StageRegistry     = "registry"
+	StageOTel         = "otel"
+	StageBasicInfo    = "basic_info"
+	StageTracer       = "tracer"
+	StageErrorHandler = "error_handler"
+	StageInflight     = "inflight"
+	StageMetaInfo     = "metainfo"
+	StageRateLimit    = "rate_limit"
+	StageShedding     = "shedding"
+	StageMiddleware   = "middleware"
+)
+
+// stageOrder 各构建阶段的执行顺序
+var stageOrder = []string{
+	StageRegistry,
+	StageOTel,
+	StageBasicInfo,
+	StageTracer,
+	StageErrorHandler,
+	StageInflight,
+	StageMetaInfo,
+	StageRateLimit,
+	StageShedding,
+	StageMiddleware,
+}
+
+// ServerStage 构建阶段，返回该阶段产生的服务器选项
+type ServerStage func(b *ServerBuilder) ([]server.Option, error)
+
+// ServerBuilder 统一的服务端套件构建器
+// 根据 hdmodel.Monitor 和注册中心类型组合注册中心、OpenTelemetry、Prometheus 和中间件，
+// 每个阶段都可以通过 WithStage 替换或禁用
+type ServerBuilder struct {
+	// ServiceName 当前服务名称
+	ServiceName string
+	// RegistryKind 注册中心类型
+	RegistryKind RegistryKind
+	// Monitor 监控配置，注册中心地址和认证信息取自 Monitor.Registry
+	Monitor *hdmodel.Monitor
+	// EnableOTelMetrics 是否通过 OpenTelemetry 上报指标
+	EnableOTelMetrics bool
+	// ErrorHandler 服务端错误处理函数，默认为 ServerErrorHandler，为 nil 时不设置
+	ErrorHandler func(ctx context.Context, err error) error
+	// Middlewares 自定义中间件
+	Middlewares []endpoint.Middleware
+	// ExtraOptions 额外的服务器选项，追加在所有阶段之后
+	ExtraOptions []server.Option
+	// Retry 注册中心阶段的启动重试策略，为 nil 时不重试
+	Retry *hdregistry.RetryPolicy
+	// Metadata 注册到注册中心的实例元数据，覆盖从环境变量读取的默认值（见 hdregistry.MetadataFromEnv）
+	Metadata map[string]string
+	// Weight 实例权重，为 0 时取 SERVICE_WEIGHT 环境变量，仍为 0 时使用 Kitex 默认权重
+	Weight int
+	// Inflight 在途请求统计器，默认为 DefaultInflightTracker，供优雅退出时排空请求
+	Inflight *InflightTracker
+	// MetaInfo 元数据传递策略，默认只补全请求 ID 和追踪 ID，不检查必需字段，为 nil 时不启用
+	MetaInfo *MetaInfoPolicy
+	// Limiter 服务端限流器，为 nil 时不限流
+	Limiter *Limiter
+	// Shedder 自适应降载器，为 nil 时不降载
+	Shedder *Shedder
+	// Lifecycle 生命周期管理器，各阶段创建的组件注册到这里，默认为 lifecycle.Default()
+	Lifecycle *lifecycle.Lifecycle
+
+	stages map[string]ServerStage
+}
+
+// NewServerBuilder 创建服务端套件构建器
+func NewServerBuilder(serviceName string, kind RegistryKind, cfg *hdmodel.Monitor) *ServerBuilder {
+	if cfg == nil {
+		cfg = &hdmodel.Monitor{}
+	}
+	return &ServerBuilder{
+		ServiceName:  serviceName,
+		RegistryKind: kind,
+		Monitor:      cfg,
+		ErrorHandler: ServerErrorHandler,
+		Inflight:     DefaultInflightTracker,
+		MetaInfo:     &MetaInfoPolicy{},
+		Lifecycle:    lifecycle.Default(),
+		stages: map[string]ServerStage{
+			StageRegistry:     RegistryStage,
+			StageOTel:         OTelStage,
+			StageBasicInfo:    BasicInfoStage,
+			StageTracer:       TracerStage,
+			StageErrorHandler: ErrorHandlerStage,
+			StageInflight:     InflightStage,
+			StageMetaInfo:     MetaInfoStage,
+			StageRateLimit:    RateLimitStage,
+			StageShedding:     SheddingStage,
+			StageMiddleware:   MiddlewareStage,
+		},
+	}
+}
+
+// WithStage 替换指定阶段，stage 为 nil 时禁用该阶段
+func (b *ServerBuilder) WithStage(name string, stage ServerStage) *ServerBuilder {
+	b.stages[name] = stage
+	return b
+}
+
+// WithErrorHandler 设置服务端错误处理函数
+func (b *ServerBuilder) WithErrorHandler(handler func(ctx context.Context, err error) error) *ServerBuilder {
+	b.ErrorHandler = handler
+	return b
+}
+
+// WithMiddleware 追加自定义中间件
+func (b *ServerBuilder) WithMiddleware(mws ...endpoint.Middleware) *ServerBuilder {
+	b.Middlewares = append(b.Middlewares, mws...)
+	return b
+}
+
+// WithOptions 追加额外的服务器选项
+func (b *ServerBuilder) WithOptions(opts ...server.Option) *ServerBuilder {
+	b.ExtraOptions = append(b.ExtraOptions, opts...)
+	return b
+}
+
+// WithRetry 设置注册中心阶段的启动重试策略
+func (b *ServerBuilder) WithRetry(policy *hdregistry.RetryPolicy) *ServerBuilder {
+	b.Retry = policy
+	return b
+}
+
+// WithMetadata 追加实例元数据
+func (b *ServerBuilder) WithMetadata(md map[string]string) *ServerBuilder {
+	if b.Metadata == nil {
+		b.Metadata = make(map[string]string, len(md))
+	}
+	for k, v := range md {
+		b.Metadata[k] = v
+	}
+	return b
+}
+
+// WithWeight 设置实例权重
+func (b *ServerBuilder) WithWeight(weight int) *ServerBuilder {
+	b.Weight = weight
+	return b
+}
+
+// registryInfo 合并环境变量和显式设置的实例元数据与权重
+func (b *ServerBuilder) registryInfo() (*kitexregistry.Info, error) {
+	md := hdregistry.MergeMetadata(b.Metadata)
+	if b.RegistryKind == RegistryConsul {
+		// Consul 以 key:value 形式保存标签，键中不能包含冒号
+		for k := range md {
+			if strings.Contains(k, ":") {
+				return nil, fmt.Errorf("实例元数据键 '%s' 不能包含冒号", k)
+			}
+		}
+	}
+	weight := b.Weight
+	if weight == 0 {
+		weight = hdregistry.WeightFromEnv()
+	}
+	if weight < 0 {
+		return nil, fmt.Errorf("实例权重不能为负数: %d", weight)
+	}
+	// 权重为 0 时由 Kitex 填充默认权重
+	return &kitexregistry.Info{Weight: weight, Tags: md}, nil
+}
+
+// WithMetaInfo 设置元数据传递策略，p 为 nil 时保留当前策略
+func (b *ServerBuilder) WithMetaInfo(p *MetaInfoPolicy) *ServerBuilder {
+	if p != nil {
+		b.MetaInfo = p
+	}
+	return b
+}
+
+// WithLimiter 设置服务端限流器
+func (b *ServerBuilder) WithLimiter(l *Limiter) *ServerBuilder {
+	b.Limiter = l
+	return b
+}
+
+// WithShedder 设置自适应降载器
+func (b *ServerBuilder) WithShedder(s *Shedder) *ServerBuilder {
+	b.Shedder = s
+	return b
+}
+
+// Build 按阶段顺序构建服务器选项，注册中心相关错误可通过 errors.Is 与 hdregistry 中的错误类型比较
+func (b *ServerBuilder) Build() ([]server.Option, error) {
+	var opts []server.Option
+	for _, name := range stageOrder {
+		stage := b.stages[name]
+		if stage == nil {
+			continue
+		}
+
+		var stageOpts []server.Option
+		run := func() (err error) {
+			stageOpts, err = stage(b)
+			return err
+		}
+
+		var err error
+		if name == StageRegistry {
+			err = b.Retry.Do(context.Background(), run)
+		} else {
+			err = run()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("构建阶段 %s 失败: %w", name, err)
+		}
+		opts = append(opts, stageOpts...)
+	}
+	return append(opts, b.ExtraOptions...), nil
+}
+
+// Options 按阶段顺序构建服务器选项，失败时直接退出进程
+func (b *ServerBuilder) Options() []server.Option {
+	opts, err := b.Build()
+	if err != nil {
+		klog.Fatalf("%v", err)
+	}
+	return opts
+}
+
+// WithLifecycle 设置生命周期管理器
+func (b *ServerBuilder) WithLifecycle(l *lifecycle.Lifecycle) *ServerBuilder {
+	b.Lifecycle = l
+	return b
+}
+
+// registerComponent 将阶段中已启动的组件注册到生命周期管理器，重复构建时只记录警告
+func (b *ServerBuilder) registerComponent(name string, c lifecycle.Component, opts ...lifecycle.Option) {
+	if b.Lifecycle == nil {
+		return
+	}
+	opts = append(opts, lifecycle.Started())
+	if err := b.Lifecycle.Register(name, c, opts...); err != nil {
+		klog.Warnf("注册生命周期组件失败: %v", err)
+	}
+}
+
+// RegistryStage 根据注册中心类型创建注册器
+// 实例元数据和权重通过 server.WithRegistryInfo 随注册信息一起发布
+func RegistryStage(b *ServerBuilder) ([]server.Option, error) {
+	addr := b.Monitor.Registry.RegistryAddress
+	if b.RegistryKind == RegistryNone || b.RegistryKind == "" {
+		return nil, nil
+	}
+	info, err := b.registryInfo()
+	if err != nil {
+		return nil, err
+	}
+	switch b.RegistryKind {
+	case RegistryConsul:
+		r, err := registryconsul.NewConsulRegister(addr)
+		if err != nil {
+			return nil, hdregistry.NewRegistryError(string(RegistryConsul), addr, err)
+		}
+		return []server.Option{server.WithRegistry(r), server.WithRegistryInfo(info)}, nil
+	case RegistryNacos:
+		cli, release, err := hdregistry.AcquireNacosNamingClient(hdregistry.NewNacosConfig(b.Monitor.Registry))
+		if err != nil {
+			return nil, err
+		}
+		// 客户端与其他套件共用，关闭时释放引用，最后一个引用释放时才真正关闭
+		b.registerComponent(ComponentRegistryClient, lifecycle.Hook{
+			OnStop: func(ctx context.Context) error {
+				release()
+				return nil
+			},
+		})
+		return []server.Option{server.WithRegistry(registry.NewNacosRegistry(cli)), server.WithRegistryInfo(info)}, nil
+	default:
+		return nil, fmt.Errorf("不支持的注册中心类型: %s", b.RegistryKind)
+	}
+}
+
+// OTelStage 初始化 OpenTelemetry Provider，关闭钩子同时注册到 Kitex 和生命周期管理器，只执行一次
+// 未启用或没有上报地址时跳过
+func OTelStage(b *ServerBuilder) ([]server.Option, error) {
+	if !b.Monitor.OTel.Enable || b.Monitor.OTel.Endpoint == "" {
+		return nil, nil
+	}
+
+	p := provider.NewOpenTelemetryProvider(
+		provider.WithServiceName(b.ServiceName),
+		provider.WithExportEndpoint(b.Monitor.OTel.Endpoint),
+		provider.WithEnableMetrics(b.EnableOTelMetrics),
+		provider.WithEnableTracing(b.Monitor.EnableTracing),
+		provider.WithInsecure(),
+	)
+
+	var shutdownOnce sync.Once
+	shutdown := func(ctx context.Context) (err error) {
+		shutdownOnce.Do(func() {
+			err = p.Shutdown(ctx)
+		})
+		return err
+	}
+
+	// 注册关闭钩子
+	server.RegisterShutdownHook(func() {
+		if err := shutdown(context.Background()); err != nil {
+			klog.Errorf("关闭 OpenTelemetry provider 失败: %v", err)
+		}
+	})
+	b.registerComponent(ComponentOTelProvider, lifecycle.Hook{OnStop: shutdown})
+
+	klog.Infof("初始化 otel provider: 当前服务名称：%s 注册地址：%s 上报地址：%s",
+		b.ServiceName, b.Monitor.Registry.RegistryAddress, b.Monitor.OTel.Endpoint)
+
+	return nil, nil
+}
+
+// BasicInfoStage 设置服务基本信息
+func BasicInfoStage(b *ServerBuilder) ([]server.Option, error) {
+	return []server.Option{
+		server.WithServerBasicInfo(&rpcinfo.EndpointBasicInfo{
+			ServiceName: b.ServiceName,
+		}),
+	}, nil
+}
+
+// TracerStage 设置链路追踪和 Prometheus 指标采集
+func TracerStage(b *ServerBuilder) ([]server.Option, error) {
+	if !b.Monitor.EnableTracing {
+		return nil, nil
+	}
+
+	return []server.Option{
+		server.WithSuite(tracing.NewServerSuite()),
+		server.WithTracer(prometheus.NewServerTracer(b.ServiceName, "",
+			prometheus.WithDisableServer(true),
+			prometheus.WithRegistry(monitor.Reg))),
+	}, nil
+}
+
+// ErrorHandlerStage 设置服务端错误处理函数
+func ErrorHandlerStage(b *ServerBuilder) ([]server.Option, error) {
+	if b.ErrorHandler == nil {
+		return nil, nil
+	}
+	return []server.Option{server.WithErrorHandler(b.ErrorHandler)}, nil
+}
+
+// InflightStage 注册在途请求统计中间件
+func InflightStage(b *ServerBuilder) ([]server.Option, error) {
+	if b.Inflight == nil {
+		return nil, nil
+	}
+	return []server.Option{server.WithMiddleware(b.Inflight.Middleware)}, nil
+}
+
+// MetaInfoStage 注册元数据传递中间件，位于限流之前，限流时可以按传递后的租户和应用类型计数
+func MetaInfoStage(b *ServerBuilder) ([]server.Option, error) {
+	if b.MetaInfo == nil {
+		return nil, nil
+	}
+	return []server.Option{server.WithMiddleware(b.MetaInfo.Middleware)}, nil
+}
+
+// RateLimitStage 注册服务端限流中间件，位于在途请求统计和元数据传递之后，
+// 被限流的请求在返回错误前短暂计入在途请求，但不会执行业务处理
+func RateLimitStage(b *ServerBuilder) ([]server.Option, error) {
+	if b.Limiter == nil {
+		return nil, nil
+	}
+	return []server.Option{server.WithMiddleware(b.Limiter.Middleware)}, nil
+}
+
+// SheddingStage 启动 CPU 采样并注册自适应降载中间件，位于静态限流之后
+func SheddingStage(b *ServerBuilder) ([]server.Option, error) {
+	if b.Shedder == nil {
+		return nil, nil
+	}
+	_ = b.Shedder.Start(context.Background())
+	b.registerComponent(ComponentLoadShedder, b.Shedder)
+	return []server.Option{server.WithMiddleware(b.Shedder.Middleware)}, nil
+}
+
+// MiddlewareStage 注册自定义中间件
+func MiddlewareStage(b *ServerBuilder) ([]server.Option, error) {
+	opts := make([]server.Option, 0, len(b.Middlewares))
+	for _, mw := range b.Middlewares {
+		opts = append(opts, server.WithMiddleware(mw))
+	}
+	return opts, nil
+}
@@ -15,16 +15,9 @@
 package serversuite
 
 import (
-	"context"
-
-	"github.com/cloudwego/kitex/pkg/klog"
-	"github.com/cloudwego/kitex/pkg/rpcinfo"
 	"github.com/cloudwego/kitex/server"
-	"github.com/grayscalecloud/kitexcommon/monitor"
-	prometheus "github.com/kitex-contrib/monitor-prometheus"
-	"github.com/kitex-contrib/obs-opentelemetry/provider"
-	"github.com/kitex-contrib/obs-opentelemetry/tracing"
-	registryconsul "github.com/kitex-contrib/registry-consul"
+	"github.com/grayscalecloud/kitexcommon/hdmodel"
+	"github.com/grayscalecloud/kitexcommon/hdregistry"
 )
 
 type ConsulServerSuite struct {
@@ -33,46 +26,49 @@ type ConsulServerSuite struct {
 	OtelEndpoint       string
 	EnableMetrics      bool
 	EnableTracing      bool
+	// Retry 连接注册中心的启动重试策略，为 nil 时不重试
+	Retry *hdregistry.RetryPolicy
+	// Metadata 实例元数据，如 version、lane、zone，未设置的键取自 SERVICE_VERSION、LANE、ZONE 等环境变量
+	Metadata map[string]string
+	// Weight 实例权重，为 0 时取 SERVICE_WEIGHT 环境变量
+	Weight int
+	// Limiter 服务端限流器，为 nil 时不限流
+	Limiter *Limiter
+	// Shedder 自适应降载器，为 nil 时不降载
+	Shedder *Shedder
+	// MetaInfo 元数据传递策略，为 nil 时使用默认策略
+	MetaInfo *MetaInfoPolicy
 }
 
-func (s ConsulServerSuite) Options() []server.Option {
-	var opts []server.Option
-
-	r, err := registryconsul.NewConsulRegister(s.RegistryAddr)
-	if err != nil {
-		klog.Fatal(err)
-	}
-	opts = append(opts, server.WithRegistry(r))
-
-	if s.OtelEndpoint != "" {
-		// 初始化 OpenTelemetry Provider
-		p := provider.NewOpenTelemetryProvider(
-			provider.WithServiceName(s.CurrentServiceName), // 添加服务名
-			provider.WithExportEndpoint(s.OtelEndpoint),
-			provider.WithEnableMetrics(s.EnableMetrics),
-			provider.WithEnableTracing(s.EnableTracing),
-			provider.WithInsecure(),
-		)
-		// 注册关闭钩子
-		server.RegisterShutdownHook(func() {
-			if err := p.Shutdown(context.Background()); err != nil {
-				klog.Errorf("Failed to shutdown OpenTelemetry provider: %v", err)
-			}
-		})
+// Builder 返回 Consul 注册中心、按开关启用指标和链路追踪的构建器预设
+func (s ConsulServerSuite) Builder() *ServerBuilder {
+	cfg := &hdmodel.Monitor{
+		OTel: hdmodel.OTel{
+			Enable:   s.OtelEndpoint != "",
+			Endpoint: s.OtelEndpoint,
+		},
+		Registry: hdmodel.Registry{
+			RegistryAddress: s.RegistryAddr,
+		},
+		EnableTracing: s.EnableTracing,
 	}
+	b := NewServerBuilder(s.CurrentServiceName, RegistryConsul, cfg)
+	b.EnableOTelMetrics = s.EnableMetrics
+	b.Retry = s.Retry
+	b.Metadata = s.Metadata
+	b.Weight = s.Weight
+	b.Limiter = s.Limiter
+	b.Shedder = s.Shedder
+	b.WithMetaInfo(s.MetaInfo)
+	return b
+}
 
-	opts = append(opts,
-		server.WithServerBasicInfo(&rpcinfo.EndpointBasicInfo{
-			ServiceName: s.CurrentServiceName,
-		}),
-	)
-	if s.EnableTracing {
-		opts = append(opts,
-			server.WithSuite(tracing.NewServerSuite()),
-			server.WithTracer(prometheus.NewServerTracer(s.CurrentServiceName, "",
-				prometheus.WithDisableServer(true),
-				prometheus.WithRegistry(monitor.Reg))))
-	}
+// Build 返回服务器选项配置，失败时返回错误而不是退出进程
+func (s ConsulServerSuite) Build() ([]server.Option, error) {
+	return s.Builder().Build()
+}
 
-	return opts
+// Options 返回服务器选项配置，失败时直接退出进程
+func (s ConsulServerSuite) Options() []server.Option {
+	return s.Builder().Options()
 }
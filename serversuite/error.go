@@ -6,9 +6,13 @@ import (
 
 	"github.com/cloudwego/kitex/pkg/kerrors"
 	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/grayscalecloud/kitexcommon/consts/errno"
+	"github.com/grayscalecloud/kitexcommon/hderrors"
 )
 
 // convert errors that can be serialized
+// hderrors.BusinessError 和 errno.ErrNo 会被转换为 Kitex 业务状态码，通过 TTHeader 传递给调用方
 func ServerErrorHandler(ctx context.Context, err error) error {
 	// if you want get other rpc info, you can get rpcinfo first, like `ri := rpcinfo.GetRPCInfo(ctx)`
 	// for example, get remote address: `remoteAddr := rpcinfo.GetRPCInfo(ctx).From().Address()`
@@ -16,6 +20,13 @@ func ServerErrorHandler(ctx context.Context, err error) error {
 	if errors.Is(err, kerrors.ErrBiz) {
 		err = errors.Unwrap(err)
 	}
+	if bizErr, ok := ToBizStatusError(err); ok {
+		if setBizStatusErr(ctx, bizErr) {
+			return nil
+		}
+		// for Thrift、KitexProtobuf
+		return remote.NewTransErrorWithMsg(bizErr.BizStatusCode(), bizErr.BizMessage())
+	}
 	if errCode, ok := GetErrorCode(err); ok {
 		// for Thrift、KitexProtobuf
 		return remote.NewTransError(errCode, err)
@@ -23,6 +34,52 @@ func ServerErrorHandler(ctx context.Context, err error) error {
 	return err
 }
 
+// setBizStatusErr 将业务状态错误写入 rpcinfo，由 TTHeader 传递给调用方
+// 中间件返回的错误不会经过 ServerErrorHandler，需要直接写入
+func setBizStatusErr(ctx context.Context, bizErr kerrors.BizStatusErrorIface) bool {
+	ri := rpcinfo.GetRPCInfo(ctx)
+	if ri == nil {
+		return false
+	}
+	setter, ok := ri.Invocation().(rpcinfo.InvocationSetter)
+	if !ok {
+		return false
+	}
+	setter.SetBizStatusErr(bizErr)
+	return true
+}
+
+// GetErrorCode 获取业务错误码，支持 hderrors.BusinessError 和 errno.ErrNo
 func GetErrorCode(err error) (int32, bool) {
+	if bizErr, ok := ToBizStatusError(err); ok {
+		return bizErr.BizStatusCode(), true
+	}
 	return 0, false
 }
+
+// ToBizStatusError 将业务错误转换为 Kitex 业务状态错误
+// 支持 hderrors.BusinessError（包含 BizExtra）和 errno.ErrNo，其他错误返回 false
+func ToBizStatusError(err error) (kerrors.BizStatusErrorIface, bool) {
+	if err == nil {
+		return nil, false
+	}
+
+	var be *hderrors.BusinessError
+	if errors.As(err, &be) && be.Code != nil {
+		return kerrors.NewBizStatusErrorWithExtra(be.BizStatusCode(), be.BizMessage(), be.BizExtra()), true
+	}
+
+	var pen *errno.ErrNo
+	if errors.As(err, &pen) && pen != nil {
+		return kerrors.NewBizStatusError(int32(pen.ErrCode), pen.ErrMsg), true
+	}
+	var en errno.ErrNo
+	if errors.As(err, &en) {
+		return kerrors.NewBizStatusError(int32(en.ErrCode), en.ErrMsg), true
+	}
+
+	if bizErr, ok := kerrors.FromBizStatusError(err); ok {
+		return bizErr, true
+	}
+	return nil, false
+}
@@ -23,6 +23,8 @@ import (
 
 	"github.com/cloudwego/kitex/pkg/klog"
 	"github.com/cloudwego/kitex/server"
+	"github.com/grayscalecloud/kitexcommon/health"
+	"github.com/grayscalecloud/kitexcommon/lifecycle"
 )
 
 // GracefulShutdownOptions 优雅退出配置选项
@@ -31,15 +33,30 @@ type GracefulShutdownOptions struct {
 	// 注意：Kitex 的 Stop() 方法本身没有超时控制，这里用于包装超时逻辑
 	ShutdownTimeout time.Duration
 	// CleanupFunc 自定义清理函数，在服务器停止后执行
-	// 该函数会通过 Kitex 的 RegisterShutdownHook 注册，在 Stop() 时自动执行
+	// Deprecated: 使用 Hooks，CleanupFunc 等价于优先级为 DefaultHookPriority 的关闭钩子
 	CleanupFunc func() error
+	// Hooks 关闭钩子，在服务器停止后按优先级依次执行
+	Hooks []ShutdownHook
 	// BeforeShutdownFunc 在开始关闭前执行的函数
 	// 该函数在收到退出信号后、调用 Stop() 之前执行
-	// 可以用于：停止接收新请求、设置健康检查状态为不健康、通知其他服务等
+	// 可以用于：停止接收新请求、通知其他服务等，就绪状态会自动置为不健康
 	// 注意：Kitex 的 Stop() 会自动注销注册中心，所以不需要在这里手动注销
 	BeforeShutdownFunc func()
 	// BeforeShutdownTimeout BeforeShutdownFunc 执行的超时时间，默认 5 秒
 	BeforeShutdownTimeout time.Duration
+	// Health 健康检查注册表，收到退出信号后就绪状态会被置为 false，默认使用 health.Default()
+	Health *health.Registry
+	// DrainPeriod 就绪状态置为 false 后、调用 Stop() 前的等待时间，
+	// 用于让负载均衡和探针感知实例下线，默认 0 即不等待
+	DrainPeriod time.Duration
+	// Inflight 在途请求统计器，DrainPeriod 结束后开始拒绝新请求并等待在途请求完成，
+	// 默认使用 DefaultInflightTracker（ServerBuilder 默认已注册其中间件）
+	Inflight *InflightTracker
+	// InflightTimeout 等待在途请求完成的超时时间，默认 10 秒
+	InflightTimeout time.Duration
+	// Lifecycle 生命周期管理器，服务器启动前按依赖顺序启动其中的组件，
+	// 关闭钩子执行完成后按相反顺序关闭，默认使用 lifecycle.Default()
+	Lifecycle *lifecycle.Lifecycle
 }
 
 // defaultGracefulShutdownOptions 返回默认的优雅退出配置
@@ -47,21 +64,25 @@ func defaultGracefulShutdownOptions() *GracefulShutdownOptions {
 	return &GracefulShutdownOptions{
 		ShutdownTimeout:       30 * time.Second,
 		BeforeShutdownTimeout: 5 * time.Second,
+		InflightTimeout:       10 * time.Second,
 	}
 }
 
 // RunWithGracefulShutdown 以优雅退出的方式运行 Kitex 服务器
 // 该方法基于 Kitex 内置的优雅退出机制，提供增强功能：
-// 1. 自动监听系统信号（SIGTERM, SIGINT）
-// 2. 在停止前执行自定义钩子函数（BeforeShutdownFunc）
-// 3. 调用 Kitex 的 Stop() 方法（会自动注销注册中心并执行 ShutdownHook）
-// 4. 提供超时控制，防止无限等待
-// 5. 支持自定义清理函数（通过 RegisterShutdownHook 注册）
+// 1. 按依赖顺序启动生命周期组件，自动监听系统信号（SIGTERM, SIGINT）
+// 2. 将就绪状态置为不健康，并在停止前执行自定义钩子函数（BeforeShutdownFunc）
+// 3. 等待 DrainPeriod 让流量排空
+// 4. 拒绝新请求（返回可重试错误），等待在途请求完成，超时后记录被放弃的请求
+// 5. 调用 Kitex 的 Stop() 方法（会自动注销注册中心并执行 ShutdownHook）
+// 6. 提供超时控制，防止无限等待
+// 7. 按优先级依次执行关闭钩子（Hooks 和 CleanupFunc）
+// 8. 按启动的相反顺序关闭生命周期组件
 //
 // 注意：
 //   - Kitex 的 Stop() 方法会自动注销注册中心（如果通过 WithRegistry 配置了）
-//   - CleanupFunc 会通过 RegisterShutdownHook 注册，在 Stop() 时自动执行
-//   - BeforeShutdownFunc 在 Stop() 之前执行，可以用于设置健康检查状态等
+//   - Hooks 和 CleanupFunc 在 Stop() 之后按优先级执行，每个钩子有独立的超时时间
+//   - 就绪状态在 BeforeShutdownFunc 之前自动置为不健康，/readyz 随即返回 503
 //
 // 参数：
 //   - svr: Kitex 服务器实例
@@ -76,35 +97,31 @@ func defaultGracefulShutdownOptions() *GracefulShutdownOptions {
 //	serversuite.RunWithGracefulShutdown(svr, &serversuite.GracefulShutdownOptions{
 //		ShutdownTimeout: 30 * time.Second,
 //		BeforeShutdownFunc: func() {
-//			// 停止接收新请求、设置健康检查状态等
-//			healthCheck.SetUnhealthy()
+//			// 通知其他服务等，就绪状态会自动置为不健康
+//			notifier.Offline()
 //		},
 //		BeforeShutdownTimeout: 5 * time.Second,
-//		CleanupFunc: func() error {
-//			// 关闭数据库连接、清理资源等
-//			// 注意：这个函数会通过 RegisterShutdownHook 注册
-//			return db.Close()
+//		DrainPeriod:           5 * time.Second,
+//		Hooks: []serversuite.ShutdownHook{
+//			{Name: "mq", Priority: 10, Fn: func(ctx context.Context) error { return consumer.Shutdown() }},
+//			{Name: "db", Priority: 20, Fn: func(ctx context.Context) error { return db.Close() }},
 //		},
 //	})
 func RunWithGracefulShutdown(svr server.Server, opts *GracefulShutdownOptions) {
 	// 规范化配置选项
 	opts = normalizeOptions(opts)
 
-	// 注册清理函数到 Kitex 的 ShutdownHook（如果提供）
-	if opts.CleanupFunc != nil {
-		server.RegisterShutdownHook(func() {
-			if err := opts.CleanupFunc(); err != nil {
-				klog.Errorf("清理函数执行失败: %v", err)
-			} else {
-				klog.Infof("清理函数执行完成")
-			}
-		})
-	}
-
 	// 创建信号通道并配置 Kitex 的退出信号
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
 
+	// 启动生命周期组件（数据库、消息队列等），失败时退出
+	if err := opts.Lifecycle.Start(context.Background()); err != nil {
+		klog.Errorf("启动组件失败: %v", err)
+		os.Exit(1)
+		return
+	}
+
 	// 在 goroutine 中启动服务器
 	errChan := make(chan error, 1)
 	go func() {
@@ -129,12 +146,31 @@ func RunWithGracefulShutdown(svr server.Server, opts *GracefulShutdownOptions) {
 	sig := <-sigChan
 	klog.Infof("收到退出信号: %v，开始优雅退出...", sig)
 
+	// 就绪状态置为不健康，探针和负载均衡不再分配新流量
+	opts.Health.SetReady(false)
+	klog.Infof("就绪状态已置为不健康")
+
 	// 执行关闭前的钩子函数（在 Stop() 之前）
 	executeBeforeShutdownFunc(opts)
 
+	// 等待流量排空
+	if opts.DrainPeriod > 0 {
+		klog.Infof("等待流量排空 %v...", opts.DrainPeriod)
+		time.Sleep(opts.DrainPeriod)
+	}
+
+	// 拒绝新请求并等待在途请求完成
+	drainInflight(opts.Inflight, opts.InflightTimeout)
+
 	// 停止服务器（Kitex 会自动注销注册中心并执行 ShutdownHook）
 	stopServerWithTimeout(svr, opts.ShutdownTimeout)
 
+	// 按优先级执行关闭钩子
+	runShutdownHooks(opts.shutdownHooks())
+
+	// 按启动的相反顺序关闭生命周期组件
+	stopLifecycle(opts.Lifecycle, opts.ShutdownTimeout)
+
 	klog.Infof("优雅退出完成")
 }
 
@@ -149,9 +185,62 @@ func normalizeOptions(opts *GracefulShutdownOptions) *GracefulShutdownOptions {
 	if opts.BeforeShutdownTimeout <= 0 {
 		opts.BeforeShutdownTimeout = 5 * time.Second
 	}
+	if opts.Health == nil {
+		opts.Health = health.Default()
+	}
+	if opts.Inflight == nil {
+		opts.Inflight = DefaultInflightTracker
+	}
+	if opts.InflightTimeout <= 0 {
+		opts.InflightTimeout = 10 * time.Second
+	}
+	if opts.Lifecycle == nil {
+		opts.Lifecycle = lifecycle.Default()
+	}
 	return opts
 }
 
+// shutdownHooks 合并 Hooks 和 CleanupFunc
+func (opts *GracefulShutdownOptions) shutdownHooks() []ShutdownHook {
+	hooks := append([]ShutdownHook(nil), opts.Hooks...)
+	if opts.CleanupFunc != nil {
+		cleanup := opts.CleanupFunc
+		hooks = append(hooks, ShutdownHook{
+			Name:     "cleanup",
+			Priority: DefaultHookPriority,
+			Fn: func(ctx context.Context) error {
+				return cleanup()
+			},
+		})
+	}
+	return hooks
+}
+
+// drainInflight 拒绝新请求并等待在途请求完成，超时后记录被放弃的请求
+func drainInflight(tracker *InflightTracker, timeout time.Duration) {
+	tracker.StartDraining()
+	klog.Infof("开始拒绝新请求，等待 %d 个在途请求完成...", tracker.Inflight())
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if abandoned := tracker.Wait(ctx); len(abandoned) > 0 {
+		klog.Warnf("等待在途请求超时（%v），放弃的请求: %v", timeout, abandoned)
+		return
+	}
+	klog.Infof("在途请求已全部完成")
+}
+
+// stopLifecycle 关闭生命周期组件，每个组件有独立的超时时间，整体不超过 timeout
+func stopLifecycle(l *lifecycle.Lifecycle, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := l.Stop(ctx); err != nil {
+		klog.Errorf("关闭组件失败: %v", err)
+		return
+	}
+	klog.Infof("生命周期组件已全部关闭")
+}
+
 // executeBeforeShutdownFunc 执行关闭前的钩子函数
 func executeBeforeShutdownFunc(opts *GracefulShutdownOptions) {
 	if opts.BeforeShutdownFunc == nil {
@@ -183,7 +272,7 @@ func executeBeforeShutdownFunc(opts *GracefulShutdownOptions) {
 
 // stopServerWithTimeout 停止服务器，带超时控制
 // Kitex 的 Stop() 方法会自动：
-// 1. 执行所有通过 RegisterShutdownHook 注册的钩子函数
+// 1. 执行所有通过 server.RegisterShutdownHook 注册的钩子函数
 // 2. 注销注册中心（如果通过 WithRegistry 配置了）
 // 3. 停止服务器
 func stopServerWithTimeout(svr server.Server, timeout time.Duration) {
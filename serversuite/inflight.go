@@ -0,0 +1,124 @@
+package serversuite
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/grayscalecloud/kitexcommon/consts/errno"
+	"github.com/grayscalecloud/kitexcommon/hderrors"
+)
+
+// inflightPollInterval 等待在途请求结束时的轮询间隔
+const inflightPollInterval = 20 * time.Millisecond
+
+// InflightTracker 按方法统计在途请求，并在关闭阶段拒绝新请求
+type InflightTracker struct {
+	mu        sync.Mutex
+	perMethod map[string]int64
+	total     atomic.Int64
+	draining  atomic.Bool
+}
+
+// NewInflightTracker 创建在途请求统计器
+func NewInflightTracker() *InflightTracker {
+	return &InflightTracker{perMethod: make(map[string]int64)}
+}
+
+// DefaultInflightTracker 默认的在途请求统计器，ServerBuilder 和 RunWithGracefulShutdown 默认使用
+var DefaultInflightTracker = NewInflightTracker()
+
+// NewDrainingError 创建服务端正在关闭的错误，标记为可重试，调用方应换一个实例重试
+func NewDrainingError() *hderrors.BusinessError {
+	return hderrors.NewError(errno.Err_ServiceErr, "服务正在关闭，请重试其他实例").
+		WithExtra(hderrors.ExtraRetryable, "true")
+}
+
+// Middleware 统计在途请求的服务端中间件，关闭阶段直接返回可重试的业务错误
+func (t *InflightTracker) Middleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp interface{}) error {
+		method := methodName(ctx)
+		if !t.acquire(method) {
+			bizErr, _ := ToBizStatusError(NewDrainingError())
+			if setBizStatusErr(ctx, bizErr) {
+				return nil
+			}
+			return NewDrainingError()
+		}
+		defer t.add(method, -1)
+		return next(ctx, req, resp)
+	}
+}
+
+// acquire 计入一个在途请求，关闭阶段撤销计数并返回 false
+// 先计数再检查关闭状态，StartDraining 之后的 Wait 一定能看到所有被放行的请求
+func (t *InflightTracker) acquire(method string) bool {
+	t.add(method, 1)
+	if t.draining.Load() {
+		t.add(method, -1)
+		return false
+	}
+	return true
+}
+
+// add 调整指定方法的在途请求数
+func (t *InflightTracker) add(method string, delta int64) {
+	t.mu.Lock()
+	t.perMethod[method] += delta
+	if t.perMethod[method] == 0 {
+		delete(t.perMethod, method)
+	}
+	t.mu.Unlock()
+	t.total.Add(delta)
+}
+
+// StartDraining 进入关闭阶段，之后的新请求都会被拒绝
+func (t *InflightTracker) StartDraining() {
+	t.draining.Store(true)
+}
+
+// IsDraining 是否处于关闭阶段
+func (t *InflightTracker) IsDraining() bool {
+	return t.draining.Load()
+}
+
+// Inflight 返回当前在途请求总数
+func (t *InflightTracker) Inflight() int64 {
+	return t.total.Load()
+}
+
+// Snapshot 返回各方法当前的在途请求数
+func (t *InflightTracker) Snapshot() map[string]int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	snapshot := make(map[string]int64, len(t.perMethod))
+	for method, n := range t.perMethod {
+		snapshot[method] = n
+	}
+	return snapshot
+}
+
+// Wait 等待在途请求归零或 ctx 结束，返回被放弃的各方法在途请求数，全部完成时返回空 map
+func (t *InflightTracker) Wait(ctx context.Context) map[string]int64 {
+	ticker := time.NewTicker(inflightPollInterval)
+	defer ticker.Stop()
+	for t.Inflight() > 0 {
+		select {
+		case <-ctx.Done():
+			return t.Snapshot()
+		case <-ticker.C:
+		}
+	}
+	return map[string]int64{}
+}
+
+// methodName 从 rpcinfo 中获取方法名
+func methodName(ctx context.Context) string {
+	if ri := rpcinfo.GetRPCInfo(ctx); ri != nil && ri.Invocation() != nil {
+		return ri.Invocation().MethodName()
+	}
+	return "unknown"
+}
@@ -0,0 +1,92 @@
+package serversuite
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/grayscalecloud/kitexcommon/hderrors"
+)
+
+// methodCtx 返回携带指定方法名 rpcinfo 的 context
+func methodCtx(method string) context.Context {
+	ri := rpcinfo.NewRPCInfo(nil, nil, rpcinfo.NewInvocation("test", method), nil, nil)
+	return rpcinfo.NewCtxWithRPCInfo(context.Background(), ri)
+}
+
+func TestInflightTracker_SnapshotAndWait(t *testing.T) {
+	tracker := NewInflightTracker()
+	release := make(chan struct{})
+	entered := make(chan struct{}, 3)
+	handler := tracker.Middleware(func(ctx context.Context, req, resp interface{}) error {
+		entered <- struct{}{}
+		<-release
+		return nil
+	})
+
+	done := make(chan struct{}, 3)
+	for _, method := range []string{"Echo", "Echo", "Query"} {
+		go func(method string) {
+			_ = handler(methodCtx(method), nil, nil)
+			done <- struct{}{}
+		}(method)
+	}
+	for i := 0; i < 3; i++ {
+		<-entered
+	}
+
+	if got, want := tracker.Snapshot(), map[string]int64{"Echo": 2, "Query": 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("在途请求统计错误\n期望: %v\n实际: %v", want, got)
+	}
+	if tracker.Inflight() != 3 {
+		t.Fatalf("在途请求总数应为 3，实际 %d", tracker.Inflight())
+	}
+
+	// 超时时返回被放弃的请求
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if abandoned := tracker.Wait(ctx); len(abandoned) != 2 {
+		t.Fatalf("超时后应返回两个方法的在途请求，实际 %v", abandoned)
+	}
+
+	close(release)
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+	if abandoned := tracker.Wait(context.Background()); len(abandoned) != 0 {
+		t.Fatalf("请求全部完成后不应有被放弃的请求，实际 %v", abandoned)
+	}
+	if snapshot := tracker.Snapshot(); len(snapshot) != 0 {
+		t.Fatalf("请求全部完成后统计应为空，实际 %v", snapshot)
+	}
+}
+
+func TestInflightTracker_Draining(t *testing.T) {
+	tracker := NewInflightTracker()
+	called := false
+	handler := tracker.Middleware(func(ctx context.Context, req, resp interface{}) error {
+		called = true
+		return nil
+	})
+
+	tracker.StartDraining()
+	if !tracker.IsDraining() {
+		t.Fatalf("StartDraining 后应处于关闭阶段")
+	}
+
+	// 没有 rpcinfo 时以错误返回，便于检查
+	err := handler(context.Background(), nil, nil)
+	if called {
+		t.Fatalf("关闭阶段不应执行处理函数")
+	}
+	var be *hderrors.BusinessError
+	if !errors.As(err, &be) || !hderrors.IsRetryable(err) {
+		t.Fatalf("关闭阶段应返回可重试的业务错误，实际 %v", err)
+	}
+	if tracker.Inflight() != 0 || len(tracker.Snapshot()) != 0 {
+		t.Fatalf("被拒绝的请求不应计入在途请求，实际 %d %v", tracker.Inflight(), tracker.Snapshot())
+	}
+}
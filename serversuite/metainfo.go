@@ -0,0 +1,70 @@
+package serversuite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/grayscalecloud/kitexcommon/consts/errno"
+	"github.com/grayscalecloud/kitexcommon/ctxx"
+	"github.com/grayscalecloud/kitexcommon/hderrors"
+)
+
+// MetaInfoWildcard RequiredKeys 中适用于所有方法的键
+const MetaInfoWildcard = "*"
+
+// MetaInfoPolicy 服务端元数据传递策略
+// 中间件丢弃上游传来的隔离开关等只能本地设置的值，将其他临时值提升为持久化值，补全请求 ID 和追踪 ID，
+// 并检查各方法必需的上下文字段
+type MetaInfoPolicy struct {
+	// RequiredKeys 各方法必须携带的 ctxx 键，* 适用于所有方法
+	RequiredKeys map[string][]string
+	// RequireTenant 租户隔离开启时要求携带租户 ID
+	RequireTenant bool
+	// ExemptMethods 不做必需字段检查的方法，例如健康检查
+	ExemptMethods []string
+}
+
+// NewMissingMetaInfoError 创建缺少必需上下文字段的业务错误
+func NewMissingMetaInfoError(key string) *hderrors.BusinessError {
+	return hderrors.NewError(errno.Err_ParamsErr, fmt.Sprintf("缺少必需的上下文字段: %s", key))
+}
+
+// Middleware 服务端元数据传递中间件，缺少必需字段时返回 errno.ParamsErr 业务错误
+func (p *MetaInfoPolicy) Middleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp interface{}) error {
+		ctx = ctxx.StripLocal(ctx)
+		ctx = ctxx.Promote(ctx, ctxx.PropagatedKeys()...)
+		ctx, _ = ctxx.EnsureRequestID(ctx)
+		ctx = ctxx.WithSpanTraceID(ctx)
+
+		if key := p.missingKey(ctx, methodName(ctx)); key != "" {
+			bizErr, _ := ToBizStatusError(NewMissingMetaInfoError(key))
+			if setBizStatusErr(ctx, bizErr) {
+				return nil
+			}
+			return NewMissingMetaInfoError(key)
+		}
+		return next(ctx, req, resp)
+	}
+}
+
+// missingKey 返回第一个缺少的必需字段，全部满足时返回空字符串
+func (p *MetaInfoPolicy) missingKey(ctx context.Context, method string) string {
+	for _, exempt := range p.ExemptMethods {
+		if exempt == method {
+			return ""
+		}
+	}
+	if p.RequireTenant && ctxx.IsTenantIsolationEnabled(ctx) && ctxx.GetTenantID(ctx) == "" {
+		return ctxx.TenantKey
+	}
+	for _, keys := range [][]string{p.RequiredKeys[MetaInfoWildcard], p.RequiredKeys[method]} {
+		for _, key := range keys {
+			if !ctxx.HasMetaInfo(ctx, key) {
+				return key
+			}
+		}
+	}
+	return ""
+}
@@ -1,48 +1,36 @@
 package serversuite
 
 import (
-	"context"
 	"fmt"
-	"strconv"
-	"strings"
 
 	"github.com/cloudwego/kitex/pkg/klog"
-	"github.com/cloudwego/kitex/pkg/rpcinfo"
 	"github.com/cloudwego/kitex/server"
 	"github.com/grayscalecloud/kitexcommon/hdmodel"
-	"github.com/grayscalecloud/kitexcommon/monitor"
-	prometheus "github.com/kitex-contrib/monitor-prometheus"
-	"github.com/kitex-contrib/obs-opentelemetry/provider"
-	"github.com/kitex-contrib/obs-opentelemetry/tracing"
-	"github.com/kitex-contrib/registry-nacos/v2/registry"
-	"github.com/nacos-group/nacos-sdk-go/v2/clients"
-	naming_client "github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client"
-	"github.com/nacos-group/nacos-sdk-go/v2/common/constant"
-	"github.com/nacos-group/nacos-sdk-go/v2/vo"
+	"github.com/grayscalecloud/kitexcommon/hdregistry"
 )
 
 const (
 	// DefaultNacosPort 默认 Nacos 端口
-	DefaultNacosPort = 8848
+	DefaultNacosPort = hdregistry.DefaultNacosPort
 	// DefaultNacosAddr 默认 Nacos 地址
-	DefaultNacosAddr = "127.0.0.1"
+	DefaultNacosAddr = hdregistry.DefaultNacosHost
 	// DefaultTimeoutMs 默认超时时间（毫秒）
-	DefaultTimeoutMs = 5000
+	DefaultTimeoutMs = hdregistry.DefaultNacosTimeoutMs
 	// DefaultLogDir 默认日志目录
-	DefaultLogDir = "/tmp/nacos/log"
+	DefaultLogDir = hdregistry.DefaultNacosLogDir
 	// DefaultCacheDir 默认缓存目录
-	DefaultCacheDir = "/tmp/nacos/cache"
+	DefaultCacheDir = hdregistry.DefaultNacosCacheDir
 	// DefaultLogLevel 默认日志级别
-	DefaultLogLevel = "info"
+	DefaultLogLevel = hdregistry.DefaultNacosLogLevel
 )
 
 // NacosServerSuite Nacos 服务端套件配置
 type NacosServerSuite struct {
 	// CurrentServiceName 当前服务名称
 	CurrentServiceName string
-	// RegistryAddr 注册中心地址，格式：host:port
+	// RegistryAddr 注册中心地址，格式：host:port，集群用逗号分隔，支持 IPv6 和 http(s)://host:port/path
 	RegistryAddr string
-	// NacosPort Nacos 端口，当 RegistryAddr 未指定端口时使用
+	// NacosPort Nacos 端口，当 RegistryAddr 中的地址未指定端口时使用
 	NacosPort uint64
 	// NamespaceId Nacos 命名空间 ID
 	NamespaceId string
@@ -50,36 +38,49 @@ type NacosServerSuite struct {
 	Username string
 	// Password Nacos 认证密码
 	Password string
+	// ContextPath Nacos 上下文路径
+	ContextPath string
+	// AccessKey Nacos AK 认证
+	AccessKey string
+	// SecretKey Nacos SK 认证
+	SecretKey string
+	// TLS Nacos TLS 配置
+	TLS hdmodel.RegistryTLS
+	// LogDir Nacos 客户端日志目录，默认 DefaultLogDir
+	LogDir string
+	// CacheDir Nacos 客户端缓存目录，默认 DefaultCacheDir
+	CacheDir string
 	// Monitor 监控配置
 	Monitor *hdmodel.Monitor
+	// Retry 连接注册中心的启动重试策略，为 nil 时不重试
+	Retry *hdregistry.RetryPolicy
+	// Metadata 实例元数据，如 version、lane、zone，未设置的键取自 SERVICE_VERSION、LANE、ZONE 等环境变量
+	Metadata map[string]string
+	// Weight 实例权重，为 0 时取 SERVICE_WEIGHT 环境变量
+	Weight int
+	// Limiter 服务端限流器，为 nil 时不限流
+	Limiter *Limiter
+	// Shedder 自适应降载器，为 nil 时不降载
+	Shedder *Shedder
+	// MetaInfo 元数据传递策略，为 nil 时使用默认策略
+	MetaInfo *MetaInfoPolicy
 }
 
-// parseNacosAddr 解析 Nacos 地址和端口
-func (s NacosServerSuite) parseNacosAddr() (string, uint64, error) {
-	if s.RegistryAddr == "" {
-		return DefaultNacosAddr, DefaultNacosPort, nil
+// nacosConfig 返回 Nacos 连接配置
+func (s NacosServerSuite) nacosConfig() hdregistry.NacosConfig {
+	return hdregistry.NacosConfig{
+		Addrs:       s.RegistryAddr,
+		DefaultPort: s.NacosPort,
+		ContextPath: s.ContextPath,
+		NamespaceId: s.NamespaceId,
+		Username:    s.Username,
+		Password:    s.Password,
+		AccessKey:   s.AccessKey,
+		SecretKey:   s.SecretKey,
+		TLS:         s.TLS,
+		LogDir:      s.LogDir,
+		CacheDir:    s.CacheDir,
 	}
-
-	addr := strings.Split(s.RegistryAddr, ":")
-	if len(addr) < 1 || addr[0] == "" {
-		return DefaultNacosAddr, DefaultNacosPort, nil
-	}
-
-	serverAddr := addr[0]
-	var serverPort uint64 = DefaultNacosPort
-
-	// 解析端口
-	if len(addr) >= 2 && addr[1] != "" {
-		port, err := strconv.ParseUint(addr[1], 10, 64)
-		if err != nil {
-			return "", 0, fmt.Errorf("无效的端口号 '%s': %w", addr[1], err)
-		}
-		serverPort = port
-	} else if s.NacosPort != 0 {
-		serverPort = s.NacosPort
-	}
-
-	return serverAddr, serverPort, nil
 }
 
 // validateConfig 验证配置参数
@@ -98,117 +99,60 @@ func (s NacosServerSuite) validateConfig() error {
 	return nil
 }
 
-// createNacosClient 创建 Nacos 客户端
-func (s NacosServerSuite) createNacosClient() (naming_client.INamingClient, error) {
-	serverAddr, serverPort, err := s.parseNacosAddr()
-	if err != nil {
-		return nil, fmt.Errorf("解析 Nacos 地址失败: %w", err)
-	}
-
-	sc := []constant.ServerConfig{
-		*constant.NewServerConfig(serverAddr, serverPort),
-	}
-
-	cc := constant.ClientConfig{
-		NamespaceId:         s.NamespaceId,
-		TimeoutMs:           DefaultTimeoutMs,
-		NotLoadCacheAtStart: true,
-		LogDir:              DefaultLogDir,
-		CacheDir:            DefaultCacheDir,
-		LogLevel:            DefaultLogLevel,
-		Username:            s.Username,
-		Password:            s.Password,
+// Builder 返回 Nacos 注册中心、按 Monitor.OTel 启用链路追踪的构建器预设
+func (s NacosServerSuite) Builder() (*ServerBuilder, error) {
+	// 验证配置
+	if err := s.validateConfig(); err != nil {
+		return nil, fmt.Errorf("配置验证失败: %w", err)
 	}
 
-	cli, err := clients.NewNamingClient(
-		vo.NacosClientParam{
-			ClientConfig:  &cc,
-			ServerConfigs: sc,
-		},
-	)
+	nacosCfg := s.nacosConfig()
+	servers, err := nacosCfg.ServerConfigs()
 	if err != nil {
-		return nil, fmt.Errorf("创建 Nacos 客户端失败: %w", err)
+		return nil, err
 	}
 
-	return cli, nil
-}
-
-// setupOpenTelemetry 设置 OpenTelemetry
-func (s NacosServerSuite) setupOpenTelemetry() ([]server.Option, error) {
-	if s.Monitor == nil || !s.Monitor.OTel.Enable || s.Monitor.OTel.Endpoint == "" {
-		return nil, nil
-	}
-
-	p := provider.NewOpenTelemetryProvider(
-		provider.WithServiceName(s.CurrentServiceName),
-		provider.WithExportEndpoint(s.Monitor.OTel.Endpoint),
-		provider.WithEnableMetrics(false),
-		provider.WithEnableTracing(s.Monitor.OTel.Enable),
-		provider.WithInsecure(),
-	)
-
-	// 注册关闭钩子
-	server.RegisterShutdownHook(func() {
-		if err := p.Shutdown(context.Background()); err != nil {
-			klog.Errorf("关闭 OpenTelemetry provider 失败: %v", err)
-		}
-	})
-
-	klog.Infof("初始化 otel provider: 当前服务名称：%s 注册地址：%s 上报地址：%s",
-		s.CurrentServiceName, s.RegistryAddr, s.Monitor.OTel.Endpoint)
-
-	return nil, nil
+	cfg := hdmodel.Monitor{}
+	if s.Monitor != nil {
+		cfg = *s.Monitor
+	}
+	// 地址已按 NacosPort 补全端口，RegistryStage 解析时不再依赖 NacosPort
+	cfg.Registry.RegistryAddress = hdregistry.FormatNacosAddrs(servers)
+	cfg.Registry.NamespaceId = s.NamespaceId
+	cfg.Registry.Username = s.Username
+	cfg.Registry.Password = s.Password
+	cfg.Registry.ContextPath = s.ContextPath
+	cfg.Registry.AccessKey = s.AccessKey
+	cfg.Registry.SecretKey = s.SecretKey
+	cfg.Registry.TLS = s.TLS
+	cfg.Registry.LogDir = s.LogDir
+	cfg.Registry.CacheDir = s.CacheDir
+	// Nacos 套件以 OTel 开关同时控制链路追踪
+	cfg.EnableTracing = cfg.OTel.Enable
+
+	return NewServerBuilder(s.CurrentServiceName, RegistryNacos, &cfg).
+		WithRetry(s.Retry).
+		WithMetadata(s.Metadata).
+		WithWeight(s.Weight).
+		WithLimiter(s.Limiter).
+		WithShedder(s.Shedder).
+		WithMetaInfo(s.MetaInfo), nil
 }
 
-// setupTracing 设置链路追踪
-func (s NacosServerSuite) setupTracing() []server.Option {
-	if s.Monitor == nil || !s.Monitor.OTel.Enable {
-		return nil
-	}
-
-	return []server.Option{
-		server.WithSuite(tracing.NewServerSuite()),
-		server.WithTracer(prometheus.NewServerTracer(s.CurrentServiceName, "",
-			prometheus.WithDisableServer(true),
-			prometheus.WithRegistry(monitor.Reg))),
+// Build 返回服务器选项配置，失败时返回错误而不是退出进程
+func (s NacosServerSuite) Build() ([]server.Option, error) {
+	b, err := s.Builder()
+	if err != nil {
+		return nil, err
 	}
+	return b.Build()
 }
 
-// Options 返回服务器选项配置
+// Options 返回服务器选项配置，失败时直接退出进程
 func (s NacosServerSuite) Options() []server.Option {
-	// 验证配置
-	if err := s.validateConfig(); err != nil {
-		klog.Fatalf("配置验证失败: %v", err)
-	}
-
-	var opts []server.Option
-
-	// 创建 Nacos 客户端
-	cli, err := s.createNacosClient()
+	opts, err := s.Build()
 	if err != nil {
-		klog.Fatalf("创建 Nacos 客户端失败: %v", err)
-	}
-
-	// 设置注册中心
-	r := registry.NewNacosRegistry(cli)
-	opts = append(opts, server.WithRegistry(r))
-
-	// 设置 OpenTelemetry
-	if _, err := s.setupOpenTelemetry(); err != nil {
-		klog.Fatalf("设置 OpenTelemetry 失败: %v", err)
+		klog.Fatalf("%v", err)
 	}
-
-	// 设置服务基本信息
-	opts = append(opts,
-		server.WithServerBasicInfo(&rpcinfo.EndpointBasicInfo{
-			ServiceName: s.CurrentServiceName,
-		}),
-	)
-
-	// 设置链路追踪
-	if tracingOpts := s.setupTracing(); tracingOpts != nil {
-		opts = append(opts, tracingOpts...)
-	}
-
 	return opts
 }
@@ -0,0 +1,409 @@
+package serversuite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/grayscalecloud/kitexcommon/consts/errno"
+	"github.com/grayscalecloud/kitexcommon/ctxx"
+	"github.com/grayscalecloud/kitexcommon/hderrors"
+	"github.com/grayscalecloud/kitexcommon/kvconfig"
+	"github.com/grayscalecloud/kitexcommon/monitor"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	"gopkg.in/yaml.v2"
+)
+
+// 限流维度，同时用作指标标签
+const (
+	LimitDimensionService = "service"
+	LimitDimensionMethod  = "method"
+	LimitDimensionTenant  = "tenant"
+	LimitDimensionAppType = "app_type"
+)
+
+// LimitWildcard 租户和应用类型限流的通配键，每个租户或应用类型各自使用一份该规则的配额
+const LimitWildcard = "*"
+
+const (
+	// limitBucketIdleTTL 通配规则的令牌桶空闲超过该时间后被淘汰
+	limitBucketIdleTTL = 10 * time.Minute
+	// limitSweepInterval 检查空闲令牌桶的最小间隔
+	limitSweepInterval = time.Minute
+	// limitMaxWildcardBuckets 通配规则的令牌桶数量上限，达到上限后新的键共用同一个令牌桶
+	limitMaxWildcardBuckets = 10000
+)
+
+// 拒绝原因，用作指标标签
+const (
+	limitReasonQPS         = "qps"
+	limitReasonConcurrency = "concurrency"
+)
+
+var (
+	limitRejects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kitex_server_rate_limit_rejects_total",
+		Help: "按限流维度和键统计的服务端拒绝请求数",
+	}, []string{"dimension", "key", "reason"}) // 命中通配规则时 key 为 *
+
+	limitMetricsMu  sync.Mutex
+	limitMetricsReg *prometheus.Registry
+)
+
+// registerLimitMetrics 将限流指标注册到 monitor.Reg，monitor 未开启 Prometheus 时只计数不暴露
+func registerLimitMetrics() {
+	limitMetricsMu.Lock()
+	defer limitMetricsMu.Unlock()
+	if monitor.Reg == nil || monitor.Reg == limitMetricsReg {
+		return
+	}
+	var are prometheus.AlreadyRegisteredError
+	if err := monitor.Reg.Register(limitRejects); err != nil && !errors.As(err, &are) {
+		klog.Warnf("注册限流指标失败: %v", err)
+		return
+	}
+	limitMetricsReg = monitor.Reg
+}
+
+// LimitConfig 服务端限流配置，可以从 kvconfig 加载，各维度独立计数，请求需要同时满足所有命中的规则，例如：
+//
+//	service: {qps: 2000, max_concurrency: 500}
+//	methods:
+//	  CreateOrder: {qps: 200, burst: 50, max_concurrency: 40}
+//	tenants:
+//	  t1001: {qps: 500}
+//	  "*": {qps: 100}
+//	app_types:
+//	  merchant: {qps: 800}
+type LimitConfig struct {
+	// Service 整个服务的限流规则
+	Service *LimitRule `yaml:"service"`
+	// Methods 方法级限流规则
+	Methods map[string]LimitRule `yaml:"methods"`
+	// Tenants 租户级限流规则，* 为未单独配置的租户的默认规则
+	Tenants map[string]LimitRule `yaml:"tenants"`
+	// AppTypes 应用类型级限流规则，* 为未单独配置的应用类型的默认规则
+	AppTypes map[string]LimitRule `yaml:"app_types"`
+}
+
+// LimitRule 限流规则，各项为 0 时不限制
+type LimitRule struct {
+	// QPS 令牌桶每秒生成的令牌数
+	QPS float64 `yaml:"qps"`
+	// Burst 令牌桶容量，为 0 时取 QPS 向上取整
+	Burst int `yaml:"burst"`
+	// MaxConcurrency 最大并发请求数
+	MaxConcurrency int64 `yaml:"max_concurrency"`
+}
+
+// ParseLimitConfig 解析 YAML 或 JSON 格式的限流配置
+func ParseLimitConfig(content string) (LimitConfig, error) {
+	var cfg LimitConfig
+	if err := yaml.Unmarshal([]byte(content), &cfg); err != nil {
+		return cfg, fmt.Errorf("解析限流配置失败: %w", err)
+	}
+	if cfg.Service != nil {
+		if err := cfg.Service.validate(); err != nil {
+			return cfg, fmt.Errorf("服务限流规则无效: %w", err)
+		}
+	}
+	for dimension, rules := range map[string]map[string]LimitRule{
+		LimitDimensionMethod:  cfg.Methods,
+		LimitDimensionTenant:  cfg.Tenants,
+		LimitDimensionAppType: cfg.AppTypes,
+	} {
+		for key, rule := range rules {
+			if err := rule.validate(); err != nil {
+				return cfg, fmt.Errorf("%s %s 的限流规则无效: %w", dimension, key, err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// validate 校验限流规则
+func (r LimitRule) validate() error {
+	if r.QPS < 0 || r.Burst < 0 || r.MaxConcurrency < 0 {
+		return fmt.Errorf("限流参数不能为负数")
+	}
+	return nil
+}
+
+// burst 令牌桶容量
+func (r LimitRule) burst() int {
+	if r.Burst > 0 {
+		return r.Burst
+	}
+	return int(math.Ceil(r.QPS))
+}
+
+// NewTooManyRequestError 创建请求被限流的业务错误
+func NewTooManyRequestError() *hderrors.BusinessError {
+	return hderrors.NewError(errno.Err_TooManyRequest, "请求过于频繁，请稍后重试")
+}
+
+// Limiter 按服务、方法、租户和应用类型限制 QPS 和并发数的服务端限流器
+// 租户 ID 和应用类型由调用方传入，命中通配规则的令牌桶空闲后会被淘汰，数量也有上限
+type Limiter struct {
+	cfg             atomic.Pointer[LimitConfig]
+	buckets         sync.Map // map[limitKey]*limitBucket
+	wildcardBuckets atomic.Int64
+	lastSweep       atomic.Int64
+}
+
+// NewLimiter 创建服务端限流器，需要在 monitor 初始化之后调用才能暴露指标
+func NewLimiter(cfg LimitConfig) *Limiter {
+	l := &Limiter{}
+	l.cfg.Store(&cfg)
+	registerLimitMetrics()
+	return l
+}
+
+// Update 更新限流配置，已有的令牌桶和并发计数保留，只调整参数
+func (l *Limiter) Update(cfg LimitConfig) {
+	l.cfg.Store(&cfg)
+	klog.Infof("服务端限流配置已更新：%d 个方法，%d 个租户，%d 个应用类型",
+		len(cfg.Methods), len(cfg.Tenants), len(cfg.AppTypes))
+}
+
+// Watch 从配置中心加载限流配置并监听变化，配置解析失败时保留当前配置
+func (l *Limiter) Watch(f *kvconfig.ConfigFactory, dataId, group string) error {
+	content, err := f.GetKvConfig(dataId, group)
+	if err != nil {
+		return fmt.Errorf("获取限流配置失败: %w", err)
+	}
+	cfg, err := ParseLimitConfig(content)
+	if err != nil {
+		return err
+	}
+	l.Update(cfg)
+
+	return f.ListenConfig(dataId, group, func(content string) {
+		cfg, err := ParseLimitConfig(content)
+		if err != nil {
+			klog.Errorf("限流配置变更无效，继续使用当前配置: %v", err)
+			return
+		}
+		l.Update(cfg)
+	})
+}
+
+// Middleware 服务端限流中间件，被拒绝的请求返回 errno.TooManyRequest 业务错误
+func (l *Limiter) Middleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp interface{}) error {
+		release, ok := l.Acquire(ctx)
+		if !ok {
+			bizErr, _ := ToBizStatusError(NewTooManyRequestError())
+			if setBizStatusErr(ctx, bizErr) {
+				return nil
+			}
+			return NewTooManyRequestError()
+		}
+		defer release()
+		return next(ctx, req, resp)
+	}
+}
+
+// Acquire 按请求命中的所有规则申请配额，成功时返回释放并发配额的函数
+func (l *Limiter) Acquire(ctx context.Context) (release func(), ok bool) {
+	now := time.Now()
+	l.maybeEvict(now)
+	buckets := l.match(ctx, now)
+
+	acquired := make([]*limitBucket, 0, len(buckets))
+	releaseAll := func() {
+		for _, b := range acquired {
+			b.inflight.Add(-1)
+		}
+	}
+	for _, b := range buckets {
+		if !b.acquireConcurrency() {
+			releaseAll()
+			b.reject(limitReasonConcurrency)
+			return nil, false
+		}
+		acquired = append(acquired, b)
+	}
+
+	reservations := make([]*rate.Reservation, 0, len(buckets))
+	for _, b := range buckets {
+		r, ok := b.reserve(now)
+		if !ok {
+			// 取消已预留的令牌，避免被拒绝的请求消耗其他维度的配额
+			// 必须使用预留时的时间取消，之后的时间取消立即生效的预留不会退回令牌
+			for _, prev := range reservations {
+				prev.CancelAt(now)
+			}
+			releaseAll()
+			b.reject(limitReasonQPS)
+			return nil, false
+		}
+		if r != nil {
+			reservations = append(reservations, r)
+		}
+	}
+	return releaseAll, true
+}
+
+// limitKey 令牌桶的键
+type limitKey struct {
+	dimension string
+	key       string
+}
+
+// match 返回请求命中的令牌桶
+func (l *Limiter) match(ctx context.Context, now time.Time) []*limitBucket {
+	cfg := l.cfg.Load()
+	var buckets []*limitBucket
+	if cfg.Service != nil {
+		buckets = append(buckets, l.bucket(limitKey{LimitDimensionService, LimitDimensionService}, *cfg.Service, false, now))
+	}
+	method := methodName(ctx)
+	if rule, ok := cfg.Methods[method]; ok {
+		buckets = append(buckets, l.bucket(limitKey{LimitDimensionMethod, method}, rule, false, now))
+	}
+	if tenantID := ctxx.GetTenantID(ctx); tenantID != "" {
+		if rule, wildcard, ok := lookupLimitRule(cfg.Tenants, tenantID); ok {
+			buckets = append(buckets, l.bucket(limitKey{LimitDimensionTenant, tenantID}, rule, wildcard, now))
+		}
+	}
+	if appType := ctxx.GetAppType(ctx); appType != "" {
+		if rule, wildcard, ok := lookupLimitRule(cfg.AppTypes, appType); ok {
+			buckets = append(buckets, l.bucket(limitKey{LimitDimensionAppType, appType}, rule, wildcard, now))
+		}
+	}
+	return buckets
+}
+
+// lookupLimitRule 查找单独配置的规则，没有时使用通配规则，wildcard 表示命中的是通配规则
+func lookupLimitRule(rules map[string]LimitRule, key string) (rule LimitRule, wildcard, ok bool) {
+	if rule, ok := rules[key]; ok {
+		return rule, false, true
+	}
+	rule, ok = rules[LimitWildcard]
+	return rule, ok, ok
+}
+
+// bucket 获取或创建令牌桶，规则变化时调整参数
+// 通配规则的令牌桶达到数量上限后，新的键共用该维度下键为 * 的令牌桶
+func (l *Limiter) bucket(key limitKey, rule LimitRule, wildcard bool, now time.Time) *limitBucket {
+	v, ok := l.buckets.Load(key)
+	if !ok {
+		if wildcard && l.wildcardBuckets.Load() >= limitMaxWildcardBuckets {
+			return l.bucket(limitKey{key.dimension, LimitWildcard}, rule, false, now)
+		}
+		var loaded bool
+		v, loaded = l.buckets.LoadOrStore(key, newLimitBucket(key, rule, wildcard))
+		if !loaded && wildcard {
+			l.wildcardBuckets.Add(1)
+		}
+	}
+	b := v.(*limitBucket)
+	b.lastUsed.Store(now.UnixNano())
+	b.apply(rule)
+	return b
+}
+
+// maybeEvict 距离上次检查超过 limitSweepInterval 时淘汰空闲的令牌桶，同一时间只有一个请求执行
+func (l *Limiter) maybeEvict(now time.Time) {
+	last := l.lastSweep.Load()
+	if now.UnixNano()-last < int64(limitSweepInterval) || !l.lastSweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	l.evictIdle(now)
+}
+
+// evictIdle 淘汰空闲超过 limitBucketIdleTTL 且没有在途请求的通配规则令牌桶
+func (l *Limiter) evictIdle(now time.Time) {
+	l.buckets.Range(func(k, v interface{}) bool {
+		b := v.(*limitBucket)
+		if !b.wildcard || b.inflight.Load() > 0 || now.UnixNano()-b.lastUsed.Load() < int64(limitBucketIdleTTL) {
+			return true
+		}
+		if l.buckets.CompareAndDelete(k, v) {
+			l.wildcardBuckets.Add(-1)
+		}
+		return true
+	})
+}
+
+// limitBucket 单个键的令牌桶和并发计数
+type limitBucket struct {
+	key limitKey
+	// label 指标中的 key 标签，通配规则的令牌桶为 *，避免调用方传入的值产生无限多的时间序列
+	label    string
+	wildcard bool
+	limiter  *rate.Limiter
+	inflight atomic.Int64
+	lastUsed atomic.Int64
+
+	mu   sync.Mutex
+	rule LimitRule
+}
+
+func newLimitBucket(key limitKey, rule LimitRule, wildcard bool) *limitBucket {
+	label := key.key
+	if wildcard {
+		label = LimitWildcard
+	}
+	return &limitBucket{
+		key:      key,
+		label:    label,
+		wildcard: wildcard,
+		rule:     rule,
+		limiter:  rate.NewLimiter(rate.Limit(rule.QPS), rule.burst()),
+	}
+}
+
+// apply 规则变化时调整令牌桶参数
+func (b *limitBucket) apply(rule LimitRule) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rule == rule {
+		return
+	}
+	b.rule = rule
+	b.limiter.SetLimit(rate.Limit(rule.QPS))
+	b.limiter.SetBurst(rule.burst())
+}
+
+func (b *limitBucket) current() LimitRule {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rule
+}
+
+// acquireConcurrency 申请并发配额，未限制并发时同样计数以便规则生效时立即准确
+func (b *limitBucket) acquireConcurrency() bool {
+	n := b.inflight.Add(1)
+	if limit := b.current().MaxConcurrency; limit > 0 && n > limit {
+		b.inflight.Add(-1)
+		return false
+	}
+	return true
+}
+
+// reserve 在 now 时刻预留一个令牌，未限制 QPS 时返回 nil
+func (b *limitBucket) reserve(now time.Time) (*rate.Reservation, bool) {
+	if b.current().QPS <= 0 {
+		return nil, true
+	}
+	r := b.limiter.ReserveN(now, 1)
+	if !r.OK() || r.DelayFrom(now) > 0 {
+		r.CancelAt(now)
+		return nil, false
+	}
+	return r, true
+}
+
+// reject 记录拒绝次数
+func (b *limitBucket) reject(reason string) {
+	limitRejects.WithLabelValues(b.key.dimension, b.label, reason).Inc()
+}
@@ -0,0 +1,177 @@
+package serversuite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grayscalecloud/kitexcommon/ctxx"
+)
+
+// loadBucket 返回已创建的令牌桶
+func loadBucket(t *testing.T, l *Limiter, dimension, key string) *limitBucket {
+	t.Helper()
+	v, ok := l.buckets.Load(limitKey{dimension, key})
+	if !ok {
+		t.Fatalf("令牌桶 %s/%s 不存在", dimension, key)
+	}
+	return v.(*limitBucket)
+}
+
+func TestLimiter_QPS(t *testing.T) {
+	// QPS 极小，测试期间不会生成新令牌
+	l := NewLimiter(LimitConfig{Service: &LimitRule{QPS: 0.001, Burst: 2}})
+	ctx := methodCtx("Echo")
+
+	for i := 0; i < 2; i++ {
+		release, ok := l.Acquire(ctx)
+		if !ok {
+			t.Fatalf("第 %d 个请求不应被限流", i+1)
+		}
+		release()
+	}
+	if _, ok := l.Acquire(ctx); ok {
+		t.Fatalf("令牌耗尽后的请求应被限流")
+	}
+}
+
+func TestLimiter_ConcurrencyRelease(t *testing.T) {
+	l := NewLimiter(LimitConfig{Methods: map[string]LimitRule{"Echo": {MaxConcurrency: 1}}})
+	ctx := methodCtx("Echo")
+
+	release, ok := l.Acquire(ctx)
+	if !ok {
+		t.Fatalf("第一个请求不应被限流")
+	}
+	if _, ok := l.Acquire(ctx); ok {
+		t.Fatalf("超过最大并发数的请求应被限流")
+	}
+	if _, ok := l.Acquire(methodCtx("Query")); !ok {
+		t.Fatalf("没有规则的方法不应被限流")
+	}
+
+	release()
+	release, ok = l.Acquire(ctx)
+	if !ok {
+		t.Fatalf("释放并发配额后的请求不应被限流")
+	}
+	release()
+	if n := loadBucket(t, l, LimitDimensionMethod, "Echo").inflight.Load(); n != 0 {
+		t.Fatalf("全部释放后并发计数应为 0，实际 %d", n)
+	}
+}
+
+func TestLimiter_RollbackAcrossDimensions(t *testing.T) {
+	l := NewLimiter(LimitConfig{
+		Service: &LimitRule{QPS: 0.001, Burst: 2, MaxConcurrency: 10},
+		Methods: map[string]LimitRule{"Echo": {QPS: 0.001, Burst: 1}},
+		Tenants: map[string]LimitRule{"t1": {MaxConcurrency: 1}},
+	})
+
+	// 消耗 Echo 的唯一令牌和服务的一个令牌
+	release, ok := l.Acquire(methodCtx("Echo"))
+	if !ok {
+		t.Fatalf("第一个请求不应被限流")
+	}
+	release()
+
+	// 服务维度预留令牌后方法维度被拒绝，服务维度的令牌应被取消
+	if _, ok := l.Acquire(methodCtx("Echo")); ok {
+		t.Fatalf("方法令牌耗尽后的请求应被限流")
+	}
+	if n := loadBucket(t, l, LimitDimensionService, LimitDimensionService).inflight.Load(); n != 0 {
+		t.Fatalf("被拒绝的请求应释放服务维度的并发配额，实际 %d", n)
+	}
+
+	// 租户并发被拒绝时，已申请的服务维度并发配额应被释放
+	tenantCtx := ctxx.WithTenantID(methodCtx("Query"), "t1")
+	hold, ok := l.Acquire(tenantCtx)
+	if !ok {
+		t.Fatalf("服务维度的令牌应已退回，请求不应被限流")
+	}
+	if _, ok := l.Acquire(tenantCtx); ok {
+		t.Fatalf("超过租户最大并发数的请求应被限流")
+	}
+	if n := loadBucket(t, l, LimitDimensionService, LimitDimensionService).inflight.Load(); n != 1 {
+		t.Fatalf("服务维度的并发计数应只包含放行的请求，实际 %d", n)
+	}
+	hold()
+}
+
+func TestLimiter_Wildcard(t *testing.T) {
+	l := NewLimiter(LimitConfig{Tenants: map[string]LimitRule{
+		"vip":         {MaxConcurrency: 2},
+		LimitWildcard: {MaxConcurrency: 1},
+	}})
+	ctx := methodCtx("Echo")
+
+	// 每个租户各自使用一份通配规则的配额
+	holdT1, ok := l.Acquire(ctxx.WithTenantID(ctx, "t1"))
+	if !ok {
+		t.Fatalf("租户 t1 的第一个请求不应被限流")
+	}
+	if _, ok := l.Acquire(ctxx.WithTenantID(ctx, "t1")); ok {
+		t.Fatalf("租户 t1 超过通配规则的并发数应被限流")
+	}
+	holdT2, ok := l.Acquire(ctxx.WithTenantID(ctx, "t2"))
+	if !ok {
+		t.Fatalf("租户 t2 不应受租户 t1 的配额影响")
+	}
+	for i := 0; i < 2; i++ {
+		if _, ok := l.Acquire(ctxx.WithTenantID(ctx, "vip")); !ok {
+			t.Fatalf("单独配置的租户应使用自己的规则")
+		}
+	}
+
+	if b := loadBucket(t, l, LimitDimensionTenant, "t1"); !b.wildcard || b.label != LimitWildcard {
+		t.Fatalf("通配规则的令牌桶指标标签应为 *，实际 %q", b.label)
+	}
+	if b := loadBucket(t, l, LimitDimensionTenant, "vip"); b.wildcard || b.label != "vip" {
+		t.Fatalf("单独配置的令牌桶指标标签应为租户 ID，实际 %q", b.label)
+	}
+	if n := l.wildcardBuckets.Load(); n != 2 {
+		t.Fatalf("应有 2 个通配规则的令牌桶，实际 %d", n)
+	}
+
+	// 有在途请求的令牌桶不会被淘汰，单独配置的令牌桶不会被淘汰
+	holdT2()
+	l.evictIdle(time.Now().Add(limitBucketIdleTTL + time.Second))
+	if _, ok := l.buckets.Load(limitKey{LimitDimensionTenant, "t2"}); ok {
+		t.Fatalf("空闲的通配规则令牌桶应被淘汰")
+	}
+	loadBucket(t, l, LimitDimensionTenant, "t1")
+	loadBucket(t, l, LimitDimensionTenant, "vip")
+	if n := l.wildcardBuckets.Load(); n != 1 {
+		t.Fatalf("淘汰后应剩 1 个通配规则的令牌桶，实际 %d", n)
+	}
+	holdT1()
+}
+
+func TestLimiter_Update(t *testing.T) {
+	l := NewLimiter(LimitConfig{Methods: map[string]LimitRule{"Echo": {QPS: 0.001, Burst: 1, MaxConcurrency: 1}}})
+	ctx := methodCtx("Echo")
+
+	release, ok := l.Acquire(ctx)
+	if !ok {
+		t.Fatalf("第一个请求不应被限流")
+	}
+	if _, ok := l.Acquire(ctx); ok {
+		t.Fatalf("超过限制的请求应被限流")
+	}
+
+	// 放宽规则后已有的令牌桶和并发计数立即按新规则生效
+	l.Update(LimitConfig{Methods: map[string]LimitRule{"Echo": {MaxConcurrency: 2}}})
+	hold, ok := l.Acquire(ctx)
+	if !ok {
+		t.Fatalf("放宽规则后的请求不应被限流")
+	}
+	if _, ok := l.Acquire(ctx); ok {
+		t.Fatalf("并发计数应保留，超过新的最大并发数的请求应被限流")
+	}
+	release()
+	hold()
+
+	if rule := loadBucket(t, l, LimitDimensionMethod, "Echo").current(); rule.QPS != 0 || rule.MaxConcurrency != 2 {
+		t.Fatalf("令牌桶应使用新规则，实际 %+v", rule)
+	}
+}
@@ -15,66 +15,58 @@
 package serversuite
 
 import (
-	"context"
-
-	"github.com/cloudwego/kitex/pkg/klog"
-	"github.com/cloudwego/kitex/pkg/rpcinfo"
 	"github.com/cloudwego/kitex/pkg/transmeta"
 	"github.com/cloudwego/kitex/server"
-	"github.com/grayscalecloud/kitexcommon/monitor"
-	prometheus "github.com/kitex-contrib/monitor-prometheus"
-	"github.com/kitex-contrib/obs-opentelemetry/provider"
-	"github.com/kitex-contrib/obs-opentelemetry/tracing"
-	registryconsul "github.com/kitex-contrib/registry-consul"
+	"github.com/grayscalecloud/kitexcommon/hdmodel"
+	"github.com/grayscalecloud/kitexcommon/hdregistry"
 )
 
 type CommonServerSuite struct {
 	CurrentServiceName string
 	RegistryAddr       string
 	OtelEndpoint       string
+	// Retry 连接注册中心的启动重试策略，为 nil 时不重试
+	Retry *hdregistry.RetryPolicy
+	// Metadata 实例元数据，如 version、lane、zone，未设置的键取自 SERVICE_VERSION、LANE、ZONE 等环境变量
+	Metadata map[string]string
+	// Weight 实例权重，为 0 时取 SERVICE_WEIGHT 环境变量
+	Weight int
+	// Limiter 服务端限流器，为 nil 时不限流
+	Limiter *Limiter
+	// Shedder 自适应降载器，为 nil 时不降载
+	Shedder *Shedder
+	// MetaInfo 元数据传递策略，为 nil 时使用默认策略
+	MetaInfo *MetaInfoPolicy
 }
 
-func (s CommonServerSuite) Options() []server.Option {
-	opts := []server.Option{
-		server.WithMetaHandler(transmeta.ServerTTHeaderHandler), // 使用 TTHeader 协议的元数据处理器
-	}
-
-	r, err := registryconsul.NewConsulRegister(s.RegistryAddr)
-	if err != nil {
-		klog.Fatal(err)
+// Builder 返回 Consul 注册中心、始终开启链路追踪的构建器预设
+func (s CommonServerSuite) Builder() *ServerBuilder {
+	cfg := &hdmodel.Monitor{
+		OTel: hdmodel.OTel{
+			Enable:   true,
+			Endpoint: s.OtelEndpoint,
+		},
+		Registry: hdmodel.Registry{
+			RegistryAddress: s.RegistryAddr,
+		},
+		EnableTracing: true,
 	}
-	opts = append(opts, server.WithRegistry(r))
-
-	// ... consul 配置代码 ...
-
-	// 初始化 OpenTelemetry Provider
-	p := provider.NewOpenTelemetryProvider(
-		provider.WithServiceName(s.CurrentServiceName), // 添加服务名
-		provider.WithExportEndpoint(s.OtelEndpoint),
-		provider.WithEnableMetrics(false),
-		provider.WithEnableTracing(true),
-		provider.WithInsecure(),
-	)
-
-	// 注册关闭钩子
-	server.RegisterShutdownHook(func() {
-		if err := p.Shutdown(context.Background()); err != nil {
-			klog.Errorf("Failed to shutdown OpenTelemetry provider: %v", err)
-		}
-	})
-
-	klog.Infof("初始化 otel provider: 当前名字称：%s 注册地址：%s 上报地址：%s",
-		s.CurrentServiceName, s.RegistryAddr, s.OtelEndpoint)
+	return NewServerBuilder(s.CurrentServiceName, RegistryConsul, cfg).
+		WithRetry(s.Retry).
+		WithMetadata(s.Metadata).
+		WithWeight(s.Weight).
+		WithLimiter(s.Limiter).
+		WithShedder(s.Shedder).
+		WithMetaInfo(s.MetaInfo).
+		WithOptions(server.WithMetaHandler(transmeta.ServerTTHeaderHandler)) // 使用 TTHeader 协议的元数据处理器
+}
 
-	opts = append(opts,
-		server.WithServerBasicInfo(&rpcinfo.EndpointBasicInfo{
-			ServiceName: s.CurrentServiceName,
-		}),
-		server.WithSuite(tracing.NewServerSuite()),
-		server.WithTracer(prometheus.NewServerTracer(s.CurrentServiceName, "",
-			prometheus.WithDisableServer(true),
-			prometheus.WithRegistry(monitor.Reg))),
-	)
+// Build 返回服务器选项配置，失败时返回错误而不是退出进程
+func (s CommonServerSuite) Build() ([]server.Option, error) {
+	return s.Builder().Build()
+}
 
-	return opts
+// Options 返回服务器选项配置，失败时直接退出进程
+func (s CommonServerSuite) Options() []server.Option {
+	return s.Builder().Options()
 }
@@ -0,0 +1,384 @@
+package serversuite
+
+import (
+	"context"
+	"errors"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/grayscalecloud/kitexcommon/consts/errno"
+	"github.com/grayscalecloud/kitexcommon/ctxx"
+	"github.com/grayscalecloud/kitexcommon/hderrors"
+	"github.com/grayscalecloud/kitexcommon/monitor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 自适应降载的默认参数
+const (
+	DefaultShedCPUThreshold  = 0.8
+	DefaultShedWindow        = 5 * time.Second
+	DefaultShedBuckets       = 50
+	DefaultShedCoolDown      = time.Second
+	DefaultShedCPUSampleRate = 500 * time.Millisecond
+)
+
+// processCPUMetric 进程采集器导出的 CPU 时间指标
+const processCPUMetric = "process_cpu_seconds_total"
+
+// shedPriorityFactors 各优先级可使用的并发上限倍数，低优先级请求在并发达到上限的一半时就开始被丢弃，
+// critical 不会被丢弃
+var shedPriorityFactors = map[string]float64{
+	ctxx.PriorityLow:    0.5,
+	ctxx.PriorityNormal: 1,
+	ctxx.PriorityHigh:   1.5,
+}
+
+var (
+	shedDrops = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kitex_server_shed_drops_total",
+		Help: "自适应降载丢弃的请求数",
+	}, []string{"method", "priority"})
+	shedCPU = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kitex_server_shed_cpu_usage",
+		Help: "自适应降载采样的进程 CPU 使用率（0-1）",
+	})
+	shedMaxInflight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kitex_server_shed_max_inflight",
+		Help: "自适应降载估算的最大并发请求数",
+	})
+	shedP99 = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kitex_server_shed_p99_latency_seconds",
+		Help: "自适应降载统计窗口内的 P99 延迟",
+	})
+
+	shedMetricsMu  sync.Mutex
+	shedMetricsReg *prometheus.Registry
+)
+
+// registerShedMetrics 将降载指标注册到 monitor.Reg，monitor 未开启 Prometheus 时只计数不暴露
+func registerShedMetrics() {
+	shedMetricsMu.Lock()
+	defer shedMetricsMu.Unlock()
+	if monitor.Reg == nil || monitor.Reg == shedMetricsReg {
+		return
+	}
+	for _, c := range []prometheus.Collector{shedDrops, shedCPU, shedMaxInflight, shedP99} {
+		var are prometheus.AlreadyRegisteredError
+		if err := monitor.Reg.Register(c); err != nil && !errors.As(err, &are) {
+			klog.Warnf("注册自适应降载指标失败: %v", err)
+			return
+		}
+	}
+	shedMetricsReg = monitor.Reg
+}
+
+// ShedConfig 自适应降载配置
+type ShedConfig struct {
+	// CPUThreshold 进程 CPU 使用率（0-1，按 GOMAXPROCS 归一化）超过该值时视为过载，默认 0.8
+	CPUThreshold float64
+	// LatencyThreshold 窗口内 P99 延迟超过该值时视为过载，为 0 时只看 CPU
+	LatencyThreshold time.Duration
+	// Window 统计窗口，默认 5s
+	Window time.Duration
+	// Buckets 窗口内的桶数，默认 50
+	Buckets int
+	// CoolDown 丢弃请求后继续按过载处理的时间，避免 CPU 回落后立即放开流量，默认 1s
+	CoolDown time.Duration
+	// CPUSampleInterval CPU 采样间隔，默认 500ms
+	CPUSampleInterval time.Duration
+}
+
+// NewOverloadError 创建服务过载的业务错误，标记为可重试，调用方可以换一个实例重试
+func NewOverloadError() *hderrors.BusinessError {
+	return hderrors.NewError(errno.Err_TooManyRequest, "服务繁忙，请稍后重试").
+		WithExtra(hderrors.ExtraRetryable, "true")
+}
+
+// Shedder BBR 风格的自适应降载器
+// 根据窗口内每个桶最多完成的请求数和最小平均耗时估算服务能承受的最大并发数，
+// CPU 或 P99 延迟超过阈值时，并发超过该优先级上限的请求被直接拒绝
+type Shedder struct {
+	cfg    ShedConfig
+	bucket time.Duration
+
+	inflight    atomic.Int64
+	cpu         atomic.Uint64 // math.Float64bits
+	lastDrop    atomic.Int64  // UnixNano
+	maxInflight atomic.Int64
+	p99         atomic.Int64 // time.Duration
+
+	mu      sync.Mutex
+	window  []shedBucket
+	current int64 // 当前桶的序号，按 bucket 时长计算
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+}
+
+// shedBucket 统计窗口中的一个桶
+type shedBucket struct {
+	seq   int64
+	pass  int64
+	rtSum time.Duration
+	hist  [latencyBuckets]int64
+}
+
+// NewShedder 创建自适应降载器，需要在 monitor 初始化之后调用才能暴露指标
+func NewShedder(cfg ShedConfig) *Shedder {
+	if cfg.CPUThreshold <= 0 {
+		cfg.CPUThreshold = DefaultShedCPUThreshold
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultShedWindow
+	}
+	if cfg.Buckets <= 0 {
+		cfg.Buckets = DefaultShedBuckets
+	}
+	if cfg.CoolDown <= 0 {
+		cfg.CoolDown = DefaultShedCoolDown
+	}
+	if cfg.CPUSampleInterval <= 0 {
+		cfg.CPUSampleInterval = DefaultShedCPUSampleRate
+	}
+	registerShedMetrics()
+	return &Shedder{
+		cfg:    cfg,
+		bucket: cfg.Window / time.Duration(cfg.Buckets),
+		window: make([]shedBucket, cfg.Buckets),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Start 启动 CPU 采样，实现 lifecycle.Component 接口，第一个请求到达时也会自动启动
+func (s *Shedder) Start(ctx context.Context) error {
+	s.startOnce.Do(func() {
+		go s.sampleCPU()
+	})
+	return nil
+}
+
+// Stop 停止 CPU 采样，实现 lifecycle.Component 接口
+func (s *Shedder) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	return nil
+}
+
+// Middleware 自适应降载中间件，被丢弃的请求返回可重试的 errno.TooManyRequest 业务错误
+func (s *Shedder) Middleware(next endpoint.Endpoint) endpoint.Endpoint {
+	return func(ctx context.Context, req, resp interface{}) error {
+		_ = s.Start(ctx)
+
+		priority := ctxx.GetPriority(ctx)
+		inflight := s.inflight.Add(1)
+		overloaded := s.overloaded()
+		if overloaded {
+			span := trace.SpanFromContext(ctx)
+			span.SetAttributes(
+				attribute.Bool("shed.overloaded", true),
+				attribute.String("shed.priority", priority),
+				attribute.Float64("shed.cpu", s.CPU()),
+				attribute.Int64("shed.inflight", inflight),
+				attribute.Int64("shed.max_inflight", s.maxInflight.Load()),
+			)
+			if s.shouldDrop(priority, inflight) {
+				s.inflight.Add(-1)
+				s.lastDrop.Store(time.Now().UnixNano())
+				shedDrops.WithLabelValues(methodName(ctx), priority).Inc()
+				span.SetAttributes(attribute.Bool("shed.dropped", true))
+
+				bizErr, _ := ToBizStatusError(NewOverloadError())
+				if setBizStatusErr(ctx, bizErr) {
+					return nil
+				}
+				return NewOverloadError()
+			}
+		}
+
+		start := time.Now()
+		defer func() {
+			s.inflight.Add(-1)
+			s.record(time.Since(start))
+		}()
+		return next(ctx, req, resp)
+	}
+}
+
+// CPU 返回最近采样的 CPU 使用率
+func (s *Shedder) CPU() float64 {
+	return math.Float64frombits(s.cpu.Load())
+}
+
+// overloaded CPU 或 P99 延迟超过阈值，或者刚丢弃过请求仍在冷却期内
+func (s *Shedder) overloaded() bool {
+	if s.CPU() >= s.cfg.CPUThreshold {
+		return true
+	}
+	if s.cfg.LatencyThreshold > 0 && time.Duration(s.p99.Load()) >= s.cfg.LatencyThreshold {
+		return true
+	}
+	last := s.lastDrop.Load()
+	return last != 0 && time.Since(time.Unix(0, last)) < s.cfg.CoolDown
+}
+
+// shouldDrop 并发数超过该优先级的上限时丢弃，窗口内还没有数据时不丢弃
+func (s *Shedder) shouldDrop(priority string, inflight int64) bool {
+	if priority == ctxx.PriorityCritical {
+		return false
+	}
+	limit := s.maxInflight.Load()
+	if limit <= 0 {
+		return false
+	}
+	factor, ok := shedPriorityFactors[priority]
+	if !ok {
+		factor = shedPriorityFactors[ctxx.PriorityNormal]
+	}
+	return float64(inflight) > math.Ceil(float64(limit)*factor)
+}
+
+// record 记录一个完成的请求，进入新桶时重新计算窗口统计
+func (s *Shedder) record(rt time.Duration) {
+	seq := time.Now().UnixNano() / int64(s.bucket)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if seq != s.current {
+		s.current = seq
+		s.refresh()
+	}
+	b := &s.window[seq%int64(len(s.window))]
+	if b.seq != seq {
+		*b = shedBucket{seq: seq}
+	}
+	b.pass++
+	b.rtSum += rt
+	b.hist[latencyBucket(rt)]++
+}
+
+// refresh 根据已完成的桶计算最大并发数和 P99 延迟，调用方持有 s.mu
+func (s *Shedder) refresh() {
+	var (
+		maxPass int64
+		minRT   = time.Duration(math.MaxInt64)
+		total   int64
+		hist    [latencyBuckets]int64
+	)
+	oldest := s.current - int64(len(s.window))
+	for i := range s.window {
+		b := &s.window[i]
+		// 跳过过期的桶和尚未结束的当前桶
+		if b.pass == 0 || b.seq <= oldest || b.seq >= s.current {
+			continue
+		}
+		maxPass = max(maxPass, b.pass)
+		minRT = min(minRT, b.rtSum/time.Duration(b.pass))
+		total += b.pass
+		for j, n := range b.hist {
+			hist[j] += n
+		}
+	}
+	if total == 0 {
+		s.maxInflight.Store(0)
+		s.p99.Store(0)
+		return
+	}
+
+	// 最大并发数 = 每秒最多完成的请求数 × 最小平均耗时
+	bucketsPerSecond := float64(time.Second) / float64(s.bucket)
+	maxInflight := int64(math.Ceil(float64(maxPass) * bucketsPerSecond * minRT.Seconds()))
+	s.maxInflight.Store(max(maxInflight, 1))
+	shedMaxInflight.Set(float64(max(maxInflight, 1)))
+
+	p99 := latencyQuantile(hist, total, 0.99)
+	s.p99.Store(int64(p99))
+	shedP99.Set(p99.Seconds())
+}
+
+// sampleCPU 定时从进程采集器读取 CPU 时间，按 GOMAXPROCS 归一化后做指数平滑
+func (s *Shedder) sampleCPU() {
+	gatherer := prometheus.Gatherer(monitor.Reg)
+	if monitor.Reg == nil {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+		gatherer = reg
+	}
+
+	ticker := time.NewTicker(s.cfg.CPUSampleInterval)
+	defer ticker.Stop()
+	prevCPU, ok := processCPUSeconds(gatherer)
+	if !ok {
+		klog.Warnf("无法读取进程 CPU 时间，自适应降载只按延迟判断过载")
+		return
+	}
+	prevTime := time.Now()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+		cpu, ok := processCPUSeconds(gatherer)
+		if !ok {
+			continue
+		}
+		now := time.Now()
+		usage := (cpu - prevCPU) / now.Sub(prevTime).Seconds() / float64(runtime.GOMAXPROCS(0))
+		prevCPU, prevTime = cpu, now
+
+		// 平滑系数与 BBR 一致，避免瞬时抖动触发降载
+		smoothed := s.CPU()*0.8 + usage*0.2
+		s.cpu.Store(math.Float64bits(smoothed))
+		shedCPU.Set(smoothed)
+	}
+}
+
+// processCPUSeconds 从 Gatherer 中读取进程累计 CPU 时间
+func processCPUSeconds(g prometheus.Gatherer) (float64, bool) {
+	families, err := g.Gather()
+	if err != nil && len(families) == 0 {
+		return 0, false
+	}
+	for _, mf := range families {
+		if mf.GetName() != processCPUMetric || len(mf.GetMetric()) == 0 {
+			continue
+		}
+		return mf.GetMetric()[0].GetCounter().GetValue(), true
+	}
+	return 0, false
+}
+
+// latencyBuckets 延迟直方图的桶数，第 i 个桶的上界为 1ms × 2^(i/2)，最后一个桶约为 46 分钟
+const latencyBuckets = 32
+
+// latencyBucket 返回延迟所在的直方图桶
+func latencyBucket(rt time.Duration) int {
+	if rt <= time.Millisecond {
+		return 0
+	}
+	i := int(math.Ceil(2 * math.Log2(float64(rt)/float64(time.Millisecond))))
+	return min(i, latencyBuckets-1)
+}
+
+// latencyQuantile 返回直方图中分位数所在桶的上界
+func latencyQuantile(hist [latencyBuckets]int64, total int64, q float64) time.Duration {
+	target := int64(math.Ceil(float64(total) * q))
+	var count int64
+	for i, n := range hist {
+		count += n
+		if count >= target {
+			return time.Duration(float64(time.Millisecond) * math.Pow(2, float64(i)/2))
+		}
+	}
+	return time.Duration(float64(time.Millisecond) * math.Pow(2, float64(latencyBuckets-1)/2))
+}
@@ -0,0 +1,135 @@
+package serversuite
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grayscalecloud/kitexcommon/ctxx"
+)
+
+// bucketUpper 返回第 i 个延迟直方图桶的上界
+func bucketUpper(i int) time.Duration {
+	return time.Duration(float64(time.Millisecond) * math.Pow(2, float64(i)/2))
+}
+
+func TestShedder_ShouldDrop(t *testing.T) {
+	s := NewShedder(ShedConfig{})
+	if s.shouldDrop(ctxx.PriorityLow, 1000) {
+		t.Fatalf("窗口内没有数据时不应丢弃")
+	}
+
+	s.maxInflight.Store(10)
+	tests := []struct {
+		priority string
+		inflight int64
+		want     bool
+	}{
+		{ctxx.PriorityLow, 5, false},
+		{ctxx.PriorityLow, 6, true},
+		{ctxx.PriorityNormal, 10, false},
+		{ctxx.PriorityNormal, 11, true},
+		{ctxx.PriorityHigh, 15, false},
+		{ctxx.PriorityHigh, 16, true},
+		{ctxx.PriorityCritical, 1000, false},
+		// 未知优先级按 normal 处理
+		{"urgent", 10, false},
+		{"urgent", 11, true},
+	}
+	for _, tt := range tests {
+		if got := s.shouldDrop(tt.priority, tt.inflight); got != tt.want {
+			t.Errorf("shouldDrop(%q, %d) = %v，期望 %v", tt.priority, tt.inflight, got, tt.want)
+		}
+	}
+}
+
+func TestShedder_Refresh(t *testing.T) {
+	// 每个桶 100ms
+	s := NewShedder(ShedConfig{Window: time.Second, Buckets: 10})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = 100
+	s.refresh()
+	if s.maxInflight.Load() != 0 || s.p99.Load() != 0 {
+		t.Fatalf("窗口内没有数据时最大并发数和 P99 应为 0")
+	}
+
+	fill := func(seq, pass int64, rt time.Duration) {
+		b := &s.window[seq%int64(len(s.window))]
+		*b = shedBucket{seq: seq, pass: pass, rtSum: rt * time.Duration(pass)}
+		b.hist[latencyBucket(rt)] = pass
+	}
+	fill(98, 50, 20*time.Millisecond)
+	fill(99, 20, 10*time.Millisecond)
+	// 过期的桶和尚未结束的当前桶不参与计算
+	fill(90, 1000, time.Millisecond)
+	fill(100, 1000, time.Millisecond)
+	s.refresh()
+
+	// 50 × 10 桶/秒 × 10ms
+	if got := s.maxInflight.Load(); got != 5 {
+		t.Fatalf("最大并发数应为 5，实际 %d", got)
+	}
+	if got, want := time.Duration(s.p99.Load()), bucketUpper(latencyBucket(20*time.Millisecond)); got != want {
+		t.Fatalf("P99 应为 %v，实际 %v", want, got)
+	}
+
+	// 估算值不足 1 时至少为 1
+	for i := range s.window {
+		s.window[i] = shedBucket{}
+	}
+	fill(99, 1, time.Microsecond)
+	s.refresh()
+	if got := s.maxInflight.Load(); got != 1 {
+		t.Fatalf("最大并发数至少为 1，实际 %d", got)
+	}
+}
+
+func TestLatencyBucket(t *testing.T) {
+	tests := []struct {
+		rt   time.Duration
+		want int
+	}{
+		{0, 0},
+		{time.Millisecond, 0},
+		{time.Millisecond + time.Microsecond, 1},
+		{2 * time.Millisecond, 2},
+		{bucketUpper(9), 9},
+		{bucketUpper(9) + time.Microsecond, 10},
+		{bucketUpper(latencyBuckets - 1), latencyBuckets - 1},
+		{24 * time.Hour, latencyBuckets - 1},
+	}
+	for _, tt := range tests {
+		if got := latencyBucket(tt.rt); got != tt.want {
+			t.Errorf("latencyBucket(%v) = %d，期望 %d", tt.rt, got, tt.want)
+		}
+	}
+}
+
+func TestLatencyQuantile(t *testing.T) {
+	var hist [latencyBuckets]int64
+	hist[0] = 98
+	hist[10] = 1
+	hist[20] = 1
+
+	tests := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.5, bucketUpper(0)},
+		{0.98, bucketUpper(0)},
+		{0.99, bucketUpper(10)},
+		{1, bucketUpper(20)},
+	}
+	for _, tt := range tests {
+		if got := latencyQuantile(hist, 100, tt.q); got != tt.want {
+			t.Errorf("latencyQuantile(%v) = %v，期望 %v", tt.q, got, tt.want)
+		}
+	}
+
+	// 计数不足时返回最后一个桶的上界
+	if got, want := latencyQuantile([latencyBuckets]int64{}, 10, 0.99), bucketUpper(latencyBuckets-1); got != want {
+		t.Fatalf("计数不足时应返回 %v，实际 %v", want, got)
+	}
+}
@@ -0,0 +1,76 @@
+package serversuite
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+)
+
+const (
+	// DefaultHookPriority 关闭钩子的默认优先级
+	DefaultHookPriority = 100
+	// DefaultHookTimeout 关闭钩子的默认超时时间
+	DefaultHookTimeout = 10 * time.Second
+)
+
+// ShutdownHook 带优先级的关闭钩子，在服务器停止后按 Priority 从小到大依次执行
+type ShutdownHook struct {
+	// Name 钩子名称，用于日志
+	Name string
+	// Priority 优先级，数值越小越先执行，相同优先级按注册顺序执行
+	Priority int
+	// Timeout 执行超时时间，默认 10 秒
+	Timeout time.Duration
+	// Fn 钩子函数
+	Fn func(ctx context.Context) error
+}
+
+// runShutdownHooks 按优先级依次执行关闭钩子，单个钩子失败或超时不影响后续钩子
+func runShutdownHooks(hooks []ShutdownHook) {
+	sorted := make([]ShutdownHook, len(hooks))
+	copy(sorted, hooks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+
+	for _, hook := range sorted {
+		if hook.Fn == nil {
+			continue
+		}
+		if err := runShutdownHook(hook); err != nil {
+			klog.Errorf("关闭钩子 %s 执行失败: %v", hook.Name, err)
+		} else {
+			klog.Infof("关闭钩子 %s 执行完成", hook.Name)
+		}
+	}
+}
+
+// runShutdownHook 执行单个关闭钩子，带超时和 panic 保护
+func runShutdownHook(hook ShutdownHook) error {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("发生 panic: %v", r)
+			}
+		}()
+		done <- hook.Fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("执行超时（%v）", timeout)
+	}
+}
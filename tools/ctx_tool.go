@@ -2,14 +2,27 @@ package tools
 
 import (
 	"context"
+	"sync"
 
-	"github.com/bytedance/gopkg/cloud/metainfo"
-	"github.com/grayscalecloud/kitexcommon/consts"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/grayscalecloud/kitexcommon/ctxx"
 )
 
+// deprecatedWarned 已输出过废弃警告的访问器
+var deprecatedWarned sync.Map
+
+// warnDeprecated 每个访问器只输出一次废弃警告
+func warnDeprecated(name, replacement string) {
+	if _, loaded := deprecatedWarned.LoadOrStore(name, struct{}{}); loaded {
+		return
+	}
+	klog.Warnf("tools.%s 已废弃，请改用 ctxx.%s", name, replacement)
+}
+
 // GetCtxValue 从上下文中获取指定键的值
 //
-// 如果键不存在，则返回默认值
+// 如果键不存在，则返回默认值。key 可以是 ctxx 的键名或 consts 中的旧键名，
+// metainfo 中没有时再查找 ctx.Value(key)，值不是字符串时返回默认值
 //
 // 参数:
 //   - ctx: 上下文
@@ -18,31 +31,41 @@ import (
 //
 // 返回:
 //   - 上下文中的值或默认值
+//
+// Deprecated: 使用 ctxx.GetMetaInfo 或 ctxx.GetString
 func GetCtxValue(ctx context.Context, key string, defaultValue string) string {
-	lang, ok1 := metainfo.GetValue(ctx, key)
-	if ok1 {
-		return lang
-	} else {
-		lang := ctx.Value(key)
-		if lang != nil {
-			return lang.(string)
-		}
+	warnDeprecated("GetCtxValue:"+key, "GetMetaInfo")
+	return getCtxValue(ctx, key, defaultValue)
+}
+
+// getCtxValue 不输出废弃警告的 GetCtxValue
+func getCtxValue(ctx context.Context, key string, defaultValue string) string {
+	if ctx == nil {
 		return defaultValue
 	}
+	if value := ctxx.GetMetaInfo(ctx, key); value != "" {
+		return value
+	}
+	if value, ok := ctxx.Value[string](ctx, key); ok && value != "" {
+		return value
+	}
+	return defaultValue
 }
 
 // SetCtxValue 在上下文中设置键值对
 //
 // 参数:
 //   - ctx: 上下文
-//   - key: 键名
+//   - key: 键名，consts 中的旧键名会被转换为 ctxx 的键名
 //   - value: 值
 //
 // 返回:
 //   - 新的上下文
+//
+// Deprecated: 使用 ctxx.SetMetaInfo
 func SetCtxValue(ctx context.Context, key string, value string) context.Context {
-	ctx = metainfo.WithValue(ctx, key, value)
-	return ctx
+	warnDeprecated("SetCtxValue:"+key, "SetMetaInfo")
+	return ctxx.SetMetaInfo(ctx, key, value)
 }
 
 // GetAccountId 从上下文中获取账户ID
@@ -52,8 +75,11 @@ func SetCtxValue(ctx context.Context, key string, value string) context.Context
 //
 // 返回:
 //   - 账户ID，如果不存在则返回空字符串
+//
+// Deprecated: 使用 ctxx.GetAccountID
 func GetAccountId(ctx context.Context) string {
-	return GetCtxValue(ctx, consts.AccountID, "")
+	warnDeprecated("GetAccountId", "GetAccountID")
+	return getCtxValue(ctx, ctxx.AccountKey, "")
 }
 
 // GetLanguage 从上下文中获取语言设置
@@ -63,8 +89,11 @@ func GetAccountId(ctx context.Context) string {
 //
 // 返回:
 //   - 语言代码，如果不存在则返回默认语言
+//
+// Deprecated: 使用 ctxx.GetLanguage
 func GetLanguage(ctx context.Context) string {
-	return GetCtxValue(ctx, consts.Language, consts.DefaultLanguage)
+	warnDeprecated("GetLanguage", "GetLanguage")
+	return getCtxValue(ctx, ctxx.LanguageKey, ctxx.DefaultLanguage)
 }
 
 // GetUserID 从上下文中获取用户ID
@@ -74,8 +103,11 @@ func GetLanguage(ctx context.Context) string {
 //
 // 返回:
 //   - 用户ID，如果不存在则返回空字符串
+//
+// Deprecated: 使用 ctxx.GetUserID
 func GetUserID(ctx context.Context) string {
-	return GetCtxValue(ctx, consts.UserID, "")
+	warnDeprecated("GetUserID", "GetUserID")
+	return getCtxValue(ctx, ctxx.UserKey, "")
 }
 
 // WithTenant 向上下文中添加租户信息
@@ -86,8 +118,11 @@ func GetUserID(ctx context.Context) string {
 //
 // 返回:
 //   - 新的上下文
+//
+// Deprecated: 使用 ctxx.WithTenantID
 func WithTenant(ctx context.Context, tenantID string) context.Context {
-	return SetCtxValue(ctx, consts.TenantID, tenantID)
+	warnDeprecated("WithTenant", "WithTenantID")
+	return ctxx.WithTenantID(ctx, tenantID)
 }
 
 // GetTenant 从上下文中获取租户ID
@@ -97,8 +132,11 @@ func WithTenant(ctx context.Context, tenantID string) context.Context {
 //
 // 返回:
 //   - 租户ID，如果不存在则返回空字符串
+//
+// Deprecated: 使用 ctxx.GetTenantID
 func GetTenant(ctx context.Context) string {
-	return GetCtxValue(ctx, consts.TenantID, "")
+	warnDeprecated("GetTenant", "GetTenantID")
+	return getCtxValue(ctx, ctxx.TenantKey, "")
 }
 
 // WithTraceID 向上下文中添加追踪ID
@@ -109,8 +147,11 @@ func GetTenant(ctx context.Context) string {
 //
 // 返回:
 //   - 新的上下文
+//
+// Deprecated: 使用 ctxx.WithTraceID
 func WithTraceID(ctx context.Context, traceID string) context.Context {
-	return SetCtxValue(ctx, consts.TraceID, traceID)
+	warnDeprecated("WithTraceID", "WithTraceID")
+	return ctxx.WithTraceID(ctx, traceID)
 }
 
 // GetTraceID 从上下文中获取追踪ID
@@ -120,6 +161,9 @@ func WithTraceID(ctx context.Context, traceID string) context.Context {
 //
 // 返回:
 //   - 追踪ID，如果不存在则返回空字符串
+//
+// Deprecated: 使用 ctxx.GetTraceID
 func GetTraceID(ctx context.Context) string {
-	return GetCtxValue(ctx, consts.TraceID, "")
+	warnDeprecated("GetTraceID", "GetTraceID")
+	return getCtxValue(ctx, ctxx.TraceKey, "")
 }
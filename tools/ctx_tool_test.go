@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bytedance/gopkg/cloud/metainfo"
+	"github.com/grayscalecloud/kitexcommon/consts"
+	"github.com/grayscalecloud/kitexcommon/ctxx"
+)
+
+type ctxKey string
+
+func TestGetCtxValueNonString(t *testing.T) {
+	// ctx.Value 中的值不是字符串时返回默认值，不应 panic
+	ctx := context.WithValue(context.Background(), "count", 42)
+	if got := GetCtxValue(ctx, "count", "default"); got != "default" {
+		t.Errorf("Expected default, but got %s", got)
+	}
+	if got := GetCtxValue(nil, "count", "default"); got != "default" {
+		t.Errorf("Expected default for nil context, but got %s", got)
+	}
+}
+
+func TestLegacyTraceID(t *testing.T) {
+	// 旧键名 traceID 写入的值可以通过 ctxx 读取
+	ctx := metainfo.WithValue(context.Background(), consts.TraceID, "abc")
+	if got := ctxx.GetTraceID(ctx); got != "abc" {
+		t.Errorf("Expected abc, but got %s", got)
+	}
+	if got := GetTraceID(ctx); got != "abc" {
+		t.Errorf("Expected abc, but got %s", got)
+	}
+
+	// 通过 tools 写入的值以 ctxx 的键名存储
+	ctx = WithTraceID(context.Background(), "def")
+	if got := ctxx.GetTraceID(ctx); got != "def" {
+		t.Errorf("Expected def, but got %s", got)
+	}
+}
+
+func TestCompatibleKeys(t *testing.T) {
+	ctx := ctxx.WithTenantID(context.Background(), "t1")
+	ctx = ctxx.WithUserID(ctx, "u1")
+	if got := GetTenant(ctx); got != "t1" {
+		t.Errorf("Expected t1, but got %s", got)
+	}
+	if got := GetUserID(ctx); got != "u1" {
+		t.Errorf("Expected u1, but got %s", got)
+	}
+	if got := GetLanguage(ctx); got != consts.DefaultLanguage {
+		t.Errorf("Expected default language, but got %s", got)
+	}
+
+	ctx = SetCtxValue(ctx, consts.AccountID, "a1")
+	if got := ctxx.GetAccountID(ctx); got != "a1" {
+		t.Errorf("Expected a1, but got %s", got)
+	}
+	if got, ok := ctxx.Value[string](context.WithValue(ctx, ctxKey("k"), 1), ctxKey("k")); ok || got != "" {
+		t.Errorf("Expected no value for mismatched type, but got %q", got)
+	}
+}